@@ -0,0 +1,32 @@
+package command
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/yurykabanov/google-play-edit/internal/pretty"
+	"github.com/yurykabanov/google-play-edit/pkg/play"
+)
+
+var authLogoutCmd = &cobra.Command{
+	Use:   "logout",
+	Short: "Purge cached access tokens",
+	Long:  `Removes all access tokens cached by previous invocations.`,
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		path, err := tokenCachePath()
+		if err != nil {
+			pretty.Errorf("Unable to locate token cache: %s", err.Error())
+			exit(1)
+		}
+
+		err = play.NewFileTokenCache(path).Purge()
+		if err != nil {
+			pretty.Errorf("Unable to purge token cache: %s", err.Error())
+			exit(1)
+		}
+
+		fmt.Printf("Token cache %s purged\n", path)
+	},
+}
@@ -0,0 +1,92 @@
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/yurykabanov/google-play-edit/internal/pretty"
+	"github.com/yurykabanov/google-play-edit/pkg/play"
+	"github.com/yurykabanov/google-play-edit/pkg/task"
+)
+
+var editApplyCmd = &cobra.Command{
+	Use:   "apply [plan-file]",
+	Short: "Apply plan made by 'plan' command",
+	Long: `Create new edit and perform changes saved in the plan file.
+Nothing is changed if the state has drifted since the plan was made.`,
+	Args: cobra.ExactArgs(1),
+
+	PreRun: func(cmd *cobra.Command, args []string) {
+		viper.BindPFlag("commit", cmd.Flags().Lookup("commit"))
+	},
+
+	Run: func(cmd *cobra.Command, args []string) {
+		data, err := ioutil.ReadFile(args[0])
+		if err != nil {
+			pretty.Errorf("Unable to read plan: %s", err.Error())
+			exit(1)
+		}
+
+		var plan task.Plan
+		err = json.Unmarshal(data, &plan)
+		if err != nil {
+			pretty.Errorf("Unable to parse plan: %s", err.Error())
+			exit(1)
+		}
+
+		pretty.PrintPlan(&plan)
+		fmt.Println()
+
+		client := mustMakeHttpClient()
+		token := mustAuthenticate(client)
+		api := makeApi(client)
+
+		packageName := viper.GetString("package-name")
+
+		edit, err := api.Edits.Insert(commandCtx, token, packageName)
+		if err != nil {
+			pretty.Errorf("Unable to insert new edit: %s", err.Error())
+			exit(1)
+		}
+
+		pretty.PrintEdit(edit)
+		fmt.Println()
+
+		progress := pretty.NewProgress(os.Stdout)
+		applier := task.NewApplier(api, token, packageName, edit.Id, task.WithUploadProgress(
+			func(lang string, imageType play.EditImageType, image io.ReadSeeker, p play.UploadProgress) {
+				progress.Update(uploadName(lang, imageType, image), p.Sent, p.Total)
+			},
+		))
+
+		err = applier.Apply(commandCtx, &plan)
+		if err != nil {
+			pretty.Errorf("Unable to apply plan: %s", err.Error())
+			discardEdit(api, token, packageName, edit.Id)
+			exit(1)
+		}
+
+		if !viper.GetBool("commit") {
+			fmt.Printf("Plan is applied to edit %s, use `commit %s` to publish it\n", edit.Id, edit.Id)
+			return
+		}
+
+		_, err = api.Edits.Commit(commandCtx, token, packageName, edit.Id)
+		if err != nil {
+			pretty.Errorf("Unable to commit edit: %s", err.Error())
+			exit(1)
+		}
+
+		fmt.Printf("Plan is applied and committed\n")
+	},
+}
+
+func init() {
+	editApplyCmd.Flags().Bool("commit", false, "Commit the edit after plan is applied")
+}
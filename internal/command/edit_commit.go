@@ -1,14 +1,10 @@
 package command
 
 import (
-	"context"
-	"os"
-
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 
 	"github.com/yurykabanov/google-play-edit/internal/pretty"
-	"github.com/yurykabanov/google-play-edit/pkg/play"
 )
 
 var editCommitCmd = &cobra.Command{
@@ -19,15 +15,15 @@ var editCommitCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		client := mustMakeHttpClient()
 		token := mustAuthenticate(client)
-		api := play.NewApi(play.WithApiHttpClient(client))
+		api := makeApi(client)
 
 		packageName := viper.GetString("package-name")
 		editId := args[0]
 
-		_, err := api.Edits.Commit(context.Background(), token, packageName, editId)
+		_, err := api.Edits.Commit(commandCtx, token, packageName, editId)
 		if err != nil {
 			pretty.Errorf("Unable to commit edit: %s", err.Error())
-			os.Exit(1)
+			exit(1)
 		}
 	},
 }
@@ -0,0 +1,25 @@
+package command
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var editDiscardCmd = &cobra.Command{
+	Use:   "discard [id]",
+	Short: "Discard edit with given ID",
+	Long:  `Deletes the edit and all changes made to it, nothing is published.`,
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		client := mustMakeHttpClient()
+		token := mustAuthenticate(client)
+		api := makeApi(client)
+
+		packageName := viper.GetString("package-name")
+		editId := args[0]
+
+		if !discardEdit(api, token, packageName, editId) {
+			exit(1)
+		}
+	},
+}
@@ -1,10 +1,12 @@
 package command
 
 import (
-	"context"
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -15,6 +17,9 @@ import (
 	"github.com/yurykabanov/google-play-edit/pkg/task"
 )
 
+// Remaining edit lifetime that is worth a warning
+const editExpiryWarning = time.Hour
+
 var editInsertCmd = &cobra.Command{
 	Use:   "insert [listings-file]",
 	Short: "Insert edit",
@@ -26,70 +31,168 @@ Listings file could be CSV, YAML or JSON file.`,
 
 	PreRun: func(cmd *cobra.Command, args []string) {
 		viper.BindPFlag("phone-screenshots", cmd.Flags().Lookup("phone-screenshots"))
+		viper.BindPFlag("images", cmd.Flags().Lookup("images"))
+		viper.BindPFlag("keep-edit-on-interrupt", cmd.Flags().Lookup("keep-edit-on-interrupt"))
+		viper.BindPFlag("delete-on-failure", cmd.Flags().Lookup("delete-on-failure"))
+		viper.BindPFlag("min-edit-lifetime", cmd.Flags().Lookup("min-edit-lifetime"))
+		viper.BindPFlag("dry-run", cmd.Flags().Lookup("dry-run"))
+		viper.BindPFlag("events", cmd.Flags().Lookup("events"))
 	},
 
 	Run: func(cmd *cobra.Command, args []string) {
+		// Local files are checked before edit is inserted, so that invalid
+		// ones never leave an open edit behind
+		listings, err := loader.LoadListingsFromFile(args[0])
+		if err != nil {
+			pretty.Errorf("Unable to read new listings from file: %s", err.Error())
+			exit(1)
+		}
+
+		mustValidateListings(listings)
+
+		images, err := findAllImages(listings)
+		if err != nil {
+			pretty.Errorf("Images are not ready for upload: %s", err.Error())
+			exit(1)
+		}
+
 		client := mustMakeHttpClient()
 		token := mustAuthenticate(client)
-		api := play.NewApi(play.WithApiHttpClient(client))
+		api := makeApi(client)
 
 		packageName := viper.GetString("package-name")
 
-		edit, err := api.Edits.Insert(context.Background(), token, packageName)
+		edit, err := api.Edits.Insert(commandCtx, token, packageName)
 		if err != nil {
 			pretty.Errorf("Unable to insert new edit: %s", err.Error())
-			os.Exit(1)
+			exit(1)
 		}
 		editId := edit.Id
 
 		pretty.PrintEdit(edit)
 		fmt.Println()
 
-		listings, err := loader.LoadListingsFromFile(args[0])
-		if err != nil {
-			pretty.Errorf("Unable to read new listings from file: %s", err.Error())
-			os.Exit(1)
+		// In dry run mode tasks only read state, mutating calls are recorded
+		taskApi := api
+		var dryRun *play.DryRun
+		if viper.GetBool("dry-run") {
+			taskApi, dryRun = play.NewDryRunApi(api)
 		}
 
-		upsert := task.NewUpsert(api, token, packageName, editId)
-
-		for _, listing := range listings {
-			pretty.PrintListing(&listing)
-			fmt.Println()
+		var applied []string
+		uploaded := 0
+
+		progress := pretty.NewProgress(os.Stdout)
+		upsertOpts := []task.UpsertOption{
+			task.WithUploadProgress(
+				func(lang string, imageType play.EditImageType, image io.ReadSeeker, p play.UploadProgress) {
+					progress.Update(uploadName(lang, imageType, image), p.Sent, p.Total)
+				},
+			),
+			task.WithObserver(task.ObserverFunc(func(event task.Event) {
+				if event.Type == task.EventImageUploaded && !event.DryRun {
+					uploaded++
+				}
+			})),
+			task.WithExpiryPolicy(task.ExpiryPolicy{
+				Expiry:       edit.Expiry,
+				MinLifetime:  viper.GetDuration("min-edit-lifetime"),
+				WarnLifetime: editExpiryWarning,
+				Warn: func(left time.Duration) {
+					pretty.Warnf("Edit %s expires in %s", editId, pretty.FormatDuration(left))
+				},
+			}),
+		}
 
-			images, err := loader.FindImagesForLang(viper.GetString("phone-screenshots"), listing.Language)
+		if path := viper.GetString("events"); path != "" {
+			events, err := openEventLog(path)
 			if err != nil {
-				pretty.Errorf("Unable to find images for lang %s", listing.Language)
-				os.Exit(1)
+				pretty.Errorf("Unable to open events file: %s", err.Error())
+				failInsert(api, token, packageName, editId)
 			}
+			defer events.Close()
 
-			ch := make(chan io.ReadSeeker)
+			upsertOpts = append(upsertOpts, task.WithObserver(events))
+		}
 
-			go func(ch chan<- io.ReadSeeker) {
-				for _, image := range images {
-					f, err := os.Open(image)
-					if err != nil {
-						pretty.Errorf("Unable to find images for lang %s", listing.Language)
-						os.Exit(1)
-					}
-					ch <- f
-				}
-				close(ch)
-			}(ch)
+		upsert := task.NewUpsert(taskApi, token, packageName, editId, upsertOpts...)
 
-			err = upsert.Run(context.Background(), &listing, task.ImageTypeSources{
-				play.EditImagePhoneScreenshots: ch,
-			})
+		for _, listing := range listings {
+			pretty.PrintListing(&listing.Listing)
+			fmt.Println()
+
+			sources := make(task.ImageTypeSources)
+			for imageType, paths := range images[listing.Language] {
+				sources[imageType] = openImages(paths, func(err error) {
+					pretty.Errorf("Unable to open image: %s", err.Error())
+					failInsert(api, token, packageName, editId)
+				})
+			}
+
+			err = upsert.Run(commandCtx, &listing.Listing, listing.Fields, sources)
+			if err != nil && interrupted(err) {
+				abortInsert(api, token, packageName, editId, applied, uploaded)
+			}
 			if err != nil {
 				pretty.Errorf("Unable to update/create listing and sync screenshots: %s", err.Error())
-				os.Exit(1)
+				failInsert(api, token, packageName, editId)
 			}
 
+			applied = append(applied, listing.Language)
+
 			fmt.Println()
 		}
+
+		if dryRun != nil {
+			pretty.PrintDryRun(dryRun.Calls())
+			fmt.Println()
+			discardEdit(api, token, packageName, editId)
+		}
 	},
 }
 
+// abortInsert reports changes applied before the command was interrupted and
+// deletes the edit unless user asked to keep it.
+func abortInsert(api *play.Api, token *play.AccessToken, packageName string, editId string, applied []string, uploaded int) {
+	fmt.Println()
+	pretty.Errorf("Interrupted after %d listing(s) [%s] and %d uploaded image(s)",
+		len(applied), strings.Join(applied, ", "), uploaded)
+
+	if viper.GetBool("keep-edit-on-interrupt") && !viper.GetBool("dry-run") {
+		fmt.Printf("Edit %s is kept, use `discard %s` to delete it\n", editId, editId)
+		exit(interruptedExitCode)
+	}
+
+	discardEdit(api, token, packageName, editId)
+	exit(interruptedExitCode)
+}
+
+// failInsert terminates failed command, the edit is deleted when user asked
+// not to leave half-applied edits behind.
+func failInsert(api *play.Api, token *play.AccessToken, packageName string, editId string) {
+	if viper.GetBool("delete-on-failure") || viper.GetBool("dry-run") {
+		discardEdit(api, token, packageName, editId)
+	}
+
+	exit(1)
+}
+
+func uploadName(lang string, imageType play.EditImageType, image io.ReadSeeker) string {
+	name := fmt.Sprintf("%s/%s", lang, imageType)
+
+	if f, ok := image.(*os.File); ok {
+		name += "/" + filepath.Base(f.Name())
+	}
+
+	return name
+}
+
 func init() {
 	editInsertCmd.Flags().String("phone-screenshots", "", "Directory with phone screenshots (should be in subdirectories for each language)")
+	editInsertCmd.Flags().String("images", "", "Directory with images of all types laid out as <lang>/<imageType>/ (or <lang>/<imageType>.png for single images)")
+	editInsertCmd.Flags().Bool("keep-edit-on-interrupt", false, "Do not delete the edit when interrupted with Ctrl-C")
+	editInsertCmd.Flags().Bool("delete-on-failure", false, "Delete the edit when any step fails")
+	editInsertCmd.Flags().Bool("dry-run", false, "Only print changes that would be made, the edit is deleted afterwards")
+	editInsertCmd.Flags().String("events", "", "Write events (listing updates, image deletions, uploads, etc.) as JSON lines to this file, - for stdout")
+	editInsertCmd.Flags().Duration("min-edit-lifetime", 10*time.Minute, "Refuse to update listings and upload images when edit expires sooner than this")
 }
@@ -1,9 +1,7 @@
 package command
 
 import (
-	"context"
 	"fmt"
-	"os"
 
 	"github.com/logrusorgru/aurora"
 	"github.com/spf13/cobra"
@@ -21,34 +19,34 @@ var editListCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		client := mustMakeHttpClient()
 		token := mustAuthenticate(client)
-		api := play.NewApi(play.WithApiHttpClient(client))
+		api := makeApi(client)
 
 		packageName := viper.GetString("package-name")
 		editId := args[0]
 
-		edit, err := api.Edits.Get(context.Background(), token, packageName, editId)
+		edit, err := api.Edits.Get(commandCtx, token, packageName, editId)
 		if err != nil {
 			pretty.Errorf("Unable to query edit: %s", err.Error())
-			os.Exit(1)
+			exit(1)
 		}
 
 		pretty.PrintEdit(edit)
 		fmt.Println()
 
-		listings, err := api.Listings.List(context.Background(), token, packageName, editId)
+		listings, err := api.Listings.List(commandCtx, token, packageName, editId)
 		if err != nil {
 			pretty.Errorf("Unable to query listings: %s", err.Error())
-			os.Exit(1)
+			exit(1)
 		}
 
 		for _, listing := range listings {
 			pretty.PrintListing(&listing)
 			fmt.Println()
 
-			images, err := api.Images.List(context.Background(), token, packageName, editId, listing.Language, play.EditImagePhoneScreenshots)
+			images, err := api.Images.List(commandCtx, token, packageName, editId, listing.Language, play.EditImagePhoneScreenshots)
 			if err != nil {
 				pretty.Errorf("Unable to query images: %s", err.Error())
-				os.Exit(1)
+				exit(1)
 			}
 
 			fmt.Printf("%s:\n", aurora.Green("Phone screenshots"))
@@ -0,0 +1,113 @@
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/yurykabanov/google-play-edit/internal/loader"
+	"github.com/yurykabanov/google-play-edit/internal/pretty"
+	"github.com/yurykabanov/google-play-edit/pkg/task"
+)
+
+var editPlanCmd = &cobra.Command{
+	Use:   "plan [listings-file]",
+	Short: "Plan changes of listings and screenshots",
+	Long: `Compare listings and screenshots with the current state and save
+the changes needed into a plan file for review. Nothing is changed,
+use 'apply' to perform the plan.`,
+	Args: cobra.ExactArgs(1),
+
+	PreRun: func(cmd *cobra.Command, args []string) {
+		viper.BindPFlag("phone-screenshots", cmd.Flags().Lookup("phone-screenshots"))
+		viper.BindPFlag("images", cmd.Flags().Lookup("images"))
+		viper.BindPFlag("delete-missing", cmd.Flags().Lookup("delete-missing"))
+		viper.BindPFlag("out", cmd.Flags().Lookup("out"))
+	},
+
+	Run: func(cmd *cobra.Command, args []string) {
+		// Local files are checked before anything is requested
+		desired := mustLoadDesiredListings(args[0])
+
+		client := mustMakeHttpClient()
+		token := mustAuthenticate(client)
+		api := makeApi(client)
+
+		packageName := viper.GetString("package-name")
+
+		// Edit is only used to read current state
+		edit, err := api.Edits.Insert(commandCtx, token, packageName)
+		if err != nil {
+			pretty.Errorf("Unable to insert new edit: %s", err.Error())
+			exit(1)
+		}
+
+		plan, err := task.NewPlanner(api, token, packageName, edit.Id).
+			Plan(commandCtx, desired, viper.GetBool("delete-missing"))
+
+		// Failure to delete the edit is reported, but doesn't spoil the plan
+		ctx, cancel := cleanupCtx()
+		deleteErr := api.Edits.Delete(ctx, token, packageName, edit.Id)
+		cancel()
+		if deleteErr != nil {
+			pretty.Errorf("Unable to delete edit %s: %s", edit.Id, deleteErr.Error())
+		}
+
+		if err != nil {
+			pretty.Errorf("Unable to make plan: %s", err.Error())
+			exit(1)
+		}
+
+		data, err := json.MarshalIndent(plan, "", "  ")
+		if err == nil {
+			err = ioutil.WriteFile(viper.GetString("out"), data, 0644)
+		}
+		if err != nil {
+			pretty.Errorf("Unable to save plan: %s", err.Error())
+			exit(1)
+		}
+
+		pretty.PrintPlan(plan)
+		fmt.Println()
+		fmt.Printf("Plan is saved to %s\n", viper.GetString("out"))
+	},
+}
+
+// mustLoadDesiredListings loads listings along with paths of their images.
+func mustLoadDesiredListings(path string) []task.DesiredListing {
+	listings, err := loader.LoadListingsFromFile(path)
+	if err != nil {
+		pretty.Errorf("Unable to read new listings from file: %s", err.Error())
+		exit(1)
+	}
+
+	mustValidateListings(listings)
+
+	images, err := findAllImages(listings)
+	if err != nil {
+		pretty.Errorf("Images are not ready for upload: %s", err.Error())
+		exit(1)
+	}
+
+	desired := make([]task.DesiredListing, 0, len(listings))
+
+	for _, listing := range listings {
+		desired = append(desired, task.DesiredListing{
+			Listing: listing.Listing,
+			Fields:  listing.Fields,
+			Images:  images[listing.Language],
+		})
+	}
+
+	return desired
+}
+
+func init() {
+	editPlanCmd.Flags().String("phone-screenshots", "", "Directory with phone screenshots (should be in subdirectories for each language)")
+	editPlanCmd.Flags().String("images", "", "Directory with images of all types laid out as <lang>/<imageType>/ (or <lang>/<imageType>.png for single images)")
+	editPlanCmd.Flags().Bool("delete-missing", false, "Delete listings missing in listings file")
+	editPlanCmd.Flags().String("out", "plan.json", "Plan file path")
+}
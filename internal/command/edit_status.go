@@ -0,0 +1,38 @@
+package command
+
+import (
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/yurykabanov/google-play-edit/internal/pretty"
+	"github.com/yurykabanov/google-play-edit/pkg/play"
+)
+
+var editStatusCmd = &cobra.Command{
+	Use:   "status [id]",
+	Short: "Show status of edit with given ID",
+	Long:  `Shows when the edit expires and how much time is left.`,
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		client := mustMakeHttpClient()
+		token := mustAuthenticate(client)
+		api := makeApi(client)
+
+		packageName := viper.GetString("package-name")
+		editId := args[0]
+
+		edit, err := api.Edits.Get(commandCtx, token, packageName, editId)
+		if play.IsEditExpired(err) {
+			pretty.Errorf("Edit %s is expired", editId)
+			exit(1)
+		}
+		if err != nil {
+			pretty.Errorf("Unable to query edit: %s", err.Error())
+			exit(1)
+		}
+
+		pretty.PrintEditStatus(edit, time.Now())
+	},
+}
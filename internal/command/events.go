@@ -0,0 +1,45 @@
+package command
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+
+	"github.com/yurykabanov/google-play-edit/pkg/task"
+)
+
+// eventLog writes task events as JSON lines.
+type eventLog struct {
+	mu  sync.Mutex
+	f   *os.File
+	enc *json.Encoder
+}
+
+// openEventLog creates file at path ("-" means stdout) for task events.
+func openEventLog(path string) (*eventLog, error) {
+	f := os.Stdout
+	if path != "-" {
+		var err error
+		f, err = os.Create(path)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &eventLog{f: f, enc: json.NewEncoder(f)}, nil
+}
+
+func (log *eventLog) Observe(event task.Event) {
+	log.mu.Lock()
+	defer log.mu.Unlock()
+
+	log.enc.Encode(event)
+}
+
+func (log *eventLog) Close() error {
+	if log.f == os.Stdout {
+		return nil
+	}
+
+	return log.f.Close()
+}
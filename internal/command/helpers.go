@@ -1,20 +1,30 @@
 package command
 
 import (
-	"context"
 	"crypto/tls"
-	"encoding/json"
+	"fmt"
+	"io"
+	"log"
 	"net/http"
 	"net/url"
 	"os"
+	"path/filepath"
 	"time"
 
 	"github.com/spf13/viper"
 
+	"github.com/yurykabanov/google-play-edit/internal/loader"
 	"github.com/yurykabanov/google-play-edit/internal/pretty"
 	"github.com/yurykabanov/google-play-edit/pkg/play"
+	"github.com/yurykabanov/google-play-edit/pkg/validate"
 )
 
+// exit terminates process making sure collected telemetry is not lost.
+func exit(code int) {
+	shutdownTracing(code != 0)
+	os.Exit(code)
+}
+
 func mustMakeHttpClient() *http.Client {
 	proxyUrl := viper.GetString("proxy")
 	insecure := viper.GetBool("proxy-insecure")
@@ -27,7 +37,7 @@ func mustMakeHttpClient() *http.Client {
 		proxy, err := url.Parse(proxyUrl)
 		if err != nil {
 			pretty.Errorf("Unable to parse proxy URL: %s", err.Error())
-			os.Exit(1)
+			exit(1)
 		}
 
 		transport := &http.Transport{
@@ -43,24 +53,101 @@ func mustMakeHttpClient() *http.Client {
 		client.Transport = transport
 	}
 
+	if path := viper.GetString("cassette"); path != "" {
+		cassette, err := play.NewCassette(path, play.CassetteMode(viper.GetString("cassette-mode")), client.Transport)
+		if err != nil {
+			pretty.Errorf("Unable to open cassette: %s", err.Error())
+			exit(1)
+		}
+
+		client.Transport = cassette
+	}
+
 	return client
 }
 
-func loadServiceAccount(path string) (*play.ServiceAccount, error) {
-	var acc play.ServiceAccount
+func makeApi(client *http.Client) *play.Api {
+	middleware := []play.Middleware{
+		play.UserAgentMiddleware(fmt.Sprintf("%s/%s", appCommand, Version)),
+		play.RequestIdMiddleware(),
+		play.RetryMiddleware(viper.GetInt("retries")+1, time.Second),
+	}
+
+	// Debug middleware is the innermost one to log every retry attempt
+	if viper.GetBool("debug-http") {
+		middleware = append(middleware, play.DebugMiddleware(debugLogger()))
+	}
+
+	opts := []play.ApiClientOption{play.WithApiHttpClient(client), play.WithApiMiddleware(middleware...)}
+	if apiUrl := viper.GetString("api-url"); apiUrl != "" {
+		opts = append(opts, play.WithApiBaseUrl(apiUrl))
+	}
+
+	return play.NewApi(opts...)
+}
+
+func debugLogger() *log.Logger {
+	return log.New(os.Stderr, "[http] ", log.Ltime|log.Lmicroseconds)
+}
+
+// mustLoadCredentials loads credentials from given path or, when it is empty,
+// from Application Default Credentials.
+func mustLoadCredentials(path string) *play.ServiceAccount {
+	if path != "" && filepath.Ext(path) == ".p12" {
+		email := viper.GetString("account-email")
+		if email == "" {
+			pretty.Errorf("Service Account email (--account-email) is required for .p12 keys")
+			exit(1)
+		}
+
+		account, err := play.LoadP12ServiceAccount(path, email)
+		if err != nil {
+			pretty.Errorf("Unable to load service account: %s", err.Error())
+			exit(1)
+		}
+		return account
+	}
+
+	if path != "" {
+		account, err := play.LoadServiceAccount(path)
+		if err != nil {
+			pretty.Errorf("Unable to load service account: %s", err.Error())
+			exit(1)
+		}
+		return account
+	}
 
-	f, err := os.Open(path)
+	account, _, err := play.FindDefaultCredentials()
 	if err != nil {
-		return nil, err
+		pretty.Errorf("Neither Service Account nor Access Token was specified and default credentials are unavailable:\n%s", err.Error())
+		exit(1)
+	}
+
+	return account
+}
+
+// tokenCache returns cache configured by flags or nil when caching is disabled.
+func tokenCache() play.TokenCache {
+	// Cached tokens would make recorded sessions miss token requests
+	if viper.GetBool("no-token-cache") || viper.GetString("cassette") != "" {
+		return nil
 	}
 
-	dec := json.NewDecoder(f)
-	err = dec.Decode(&acc)
+	path, err := tokenCachePath()
 	if err != nil {
-		return nil, err
+		pretty.Errorf("Unable to locate token cache, caching disabled: %s", err.Error())
+		return nil
+	}
+
+	return play.NewFileTokenCache(path)
+}
+
+func tokenCachePath() (string, error) {
+	if path := viper.GetString("token-cache"); path != "" {
+		return path, nil
 	}
 
-	return &acc, nil
+	return play.DefaultTokenCachePath()
 }
 
 func mustAuthenticate(client *http.Client) *play.AccessToken {
@@ -68,23 +155,42 @@ func mustAuthenticate(client *http.Client) *play.AccessToken {
 	accountPath := viper.GetString("account")
 	accessToken := viper.GetString("token")
 
-	if accountPath != "" {
-		serviceAccount, err := loadServiceAccount(accountPath)
-		if err != nil {
-			pretty.Errorf("Unable to load service account: %s", err.Error())
-			os.Exit(1)
+	if accessToken != "" && accountPath == "" {
+		token = &play.AccessToken{AccessToken: accessToken, TokenType: "Bearer", ExpiresIn: 3600}
+	} else {
+		serviceAccount := mustLoadCredentials(accountPath)
+
+		opts := []play.AuthClientOption{play.WithAuthHttpClient(client)}
+		if viper.GetBool("debug-http") {
+			opts = append(opts, play.WithAuthMiddleware(play.DebugMiddleware(debugLogger())))
+		}
+		if cache := tokenCache(); cache != nil {
+			opts = append(opts, play.WithAuthTokenCache(cache, func(err error) {
+				pretty.Warnf("Unable to use token cache: %s", err.Error())
+			}))
+		}
+		if chain := viper.GetStringSlice("impersonate-service-account"); len(chain) > 0 {
+			opts = append(opts, play.WithAuthImpersonation(chain[len(chain)-1], chain[:len(chain)-1]...))
+		}
+		if iamUrl := viper.GetString("iam-url"); iamUrl != "" {
+			opts = append(opts, play.WithAuthIamUrl(iamUrl))
+		}
+		if scopes := viper.GetStringSlice("scopes"); len(scopes) > 0 {
+			opts = append(opts, play.WithAuthScopes(scopes...))
+		}
+		if subject := viper.GetString("subject"); subject != "" {
+			opts = append(opts, play.WithAuthSubject(subject))
+		}
+		if tokenUri := viper.GetString("token-uri"); tokenUri != "" {
+			opts = append(opts, play.WithAuthTokenUrl(tokenUri))
 		}
 
-		token, err = play.NewAuthClient(play.WithAuthHttpClient(client)).Authenticate(context.Background(), serviceAccount)
+		var err error
+		token, err = play.NewAuthClient(opts...).Authenticate(commandCtx, serviceAccount)
 		if err != nil {
 			pretty.Errorf("Unable to authenticate: %s", err.Error())
-			os.Exit(1)
+			exit(1)
 		}
-	} else if accessToken != "" {
-		token = &play.AccessToken{AccessToken: accessToken, TokenType: "Bearer", ExpiresIn: 3600}
-	} else {
-		pretty.Errorf("Neither Service Account nor Access Token was specified")
-		os.Exit(1)
 	}
 
 	if viper.GetBool("print-token") {
@@ -93,3 +199,117 @@ func mustAuthenticate(client *http.Client) *play.AccessToken {
 
 	return token
 }
+
+// discardEdit deletes edit and reports the outcome. It works even after the
+// command was interrupted.
+func discardEdit(api *play.Api, token *play.AccessToken, packageName string, editId string) bool {
+	ctx, cancel := cleanupCtx()
+	defer cancel()
+
+	err := api.Edits.Delete(ctx, token, packageName, editId)
+	if err != nil {
+		pretty.Errorf("Unable to delete edit %s: %s", editId, err.Error())
+		return false
+	}
+
+	fmt.Printf("Edit %s is deleted, no changes were made\n", editId)
+	return true
+}
+
+// mustValidateListings checks listings before anything is changed, warnings
+// are printed but don't stop the command.
+func mustValidateListings(listings []loader.Listing) {
+	warnings, err := loader.ValidateListings(listings)
+	for _, warning := range warnings {
+		pretty.Warnf("%s", warning.String())
+	}
+
+	if err != nil {
+		pretty.Errorf("Listings are not valid: %s", err.Error())
+		exit(1)
+	}
+}
+
+// findImages finds images of a language given by --images directory layout
+// and legacy --phone-screenshots directory.
+func findImages(lang string) (map[play.EditImageType][]string, error) {
+	images := make(map[play.EditImageType][]string)
+
+	if dir := viper.GetString("images"); dir != "" {
+		found, err := loader.FindImages(dir, lang)
+		if err != nil {
+			return nil, err
+		}
+		images = found
+	}
+
+	if dir := viper.GetString("phone-screenshots"); dir != "" {
+		if _, ok := images[play.EditImagePhoneScreenshots]; ok {
+			return nil, fmt.Errorf("phone screenshots of %s are given by both --images and --phone-screenshots", lang)
+		}
+
+		// Same as --images, language without directory keeps its screenshots
+		if _, err := os.Stat(filepath.Join(dir, lang)); os.IsNotExist(err) {
+			return images, nil
+		}
+
+		found, err := loader.FindImagesForLang(dir, lang)
+		if err != nil {
+			return nil, err
+		}
+		images[play.EditImagePhoneScreenshots] = found
+	}
+
+	return images, nil
+}
+
+// findAllImages finds images of every listing and validates them against Play
+// requirements, all violations are reported at once.
+func findAllImages(listings []loader.Listing) (map[string]map[play.EditImageType][]string, error) {
+	all := make(map[string]map[play.EditImageType][]string)
+	var violations []validate.Violation
+
+	for _, listing := range listings {
+		images, err := findImages(listing.Language)
+		if err != nil {
+			return nil, fmt.Errorf("unable to find images for lang %s: %w", listing.Language, err)
+		}
+
+		all[listing.Language] = images
+		violations = append(violations, validate.Images(images)...)
+	}
+
+	if len(violations) > 0 {
+		return nil, validate.Error{Violations: violations}
+	}
+
+	return all, nil
+}
+
+// openImages opens files one by one as they are consumed (upsert task closes
+// them once they are uploaded or skipped), fail is called when file can't be
+// opened.
+func openImages(paths []string, fail func(err error)) <-chan io.ReadSeeker {
+	ch := make(chan io.ReadSeeker)
+
+	go func() {
+		defer close(ch)
+
+		for _, path := range paths {
+			f, err := os.Open(path)
+			if err != nil {
+				fail(err)
+				return
+			}
+
+			select {
+			case ch <- f:
+			case <-commandCtx.Done():
+				f.Close()
+				return
+			}
+		}
+	}()
+
+	return ch
+}
@@ -0,0 +1,47 @@
+package command
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yurykabanov/google-play-edit/pkg/play"
+)
+
+func TestFindImages_WithoutDirectories(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+
+	// insert without --images and --phone-screenshots keeps remote images
+	images, err := findImages("en-US")
+	if assert.NoError(t, err) {
+		assert.Empty(t, images)
+	}
+}
+
+func TestFindImages_LegacyLanguageWithoutDirectory(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "en-US"), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "en-US", "1.png"), nil, 0644))
+
+	viper.Set("phone-screenshots", dir)
+
+	images, err := findImages("en-US")
+	if assert.NoError(t, err) {
+		assert.Equal(t, []string{filepath.Join(dir, "en-US", "1.png")}, images[play.EditImagePhoneScreenshots])
+	}
+
+	// Language without directory keeps remote screenshots
+	images, err = findImages("de-DE")
+	if assert.NoError(t, err) {
+		assert.Empty(t, images)
+	}
+}
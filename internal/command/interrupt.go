@@ -0,0 +1,58 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// Exit code of processes terminated by SIGINT
+const interruptedExitCode = 130
+
+// Time given to clean up after interrupt
+const cleanupTimeout = 30 * time.Second
+
+// withInterrupt returns context canceled on SIGINT or SIGTERM. Only the first
+// signal is handled gracefully, the next one terminates process immediately.
+func withInterrupt(ctx context.Context) context.Context {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	return ctx
+}
+
+// interrupted reports whether err is caused by interrupt of the command.
+func interrupted(err error) bool {
+	return errors.Is(err, context.Canceled) && commandCtx.Err() != nil
+}
+
+// cleanupCtx returns context that survives interrupt of the command, so that
+// already applied changes could still be rolled back.
+func cleanupCtx() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(detachedContext{commandCtx}, cleanupTimeout)
+}
+
+// detachedContext keeps values of parent context (e.g. trace spans) but is
+// never canceled along with it.
+type detachedContext struct {
+	context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (detachedContext) Err() error {
+	return nil
+}
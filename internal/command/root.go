@@ -2,7 +2,6 @@ package command
 
 import (
 	"fmt"
-	"os"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -15,6 +14,15 @@ var rootCmd = &cobra.Command{
 	Short: "Google Play Edit is a tool performing application editing and bulk screenshots uploading",
 	Long:  `Google Play Edit handles Android application's listings and screenshots updates.'`,
 
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		setupTracing(cmd)
+		commandCtx = withInterrupt(commandCtx)
+	},
+
+	PersistentPostRun: func(cmd *cobra.Command, args []string) {
+		shutdownTracing(false)
+	},
+
 	Run: func(cmd *cobra.Command, args []string) {
 		cmd.Help()
 	},
@@ -25,28 +33,62 @@ func init() {
 	rootCmd.AddCommand(editListCmd)
 	rootCmd.AddCommand(editInsertCmd)
 	rootCmd.AddCommand(editCommitCmd)
+	rootCmd.AddCommand(editDiscardCmd)
+	rootCmd.AddCommand(editStatusCmd)
+	rootCmd.AddCommand(editPlanCmd)
+	rootCmd.AddCommand(editApplyCmd)
+	rootCmd.AddCommand(authCmd)
 
-	rootCmd.PersistentFlags().String("account", "", "Google Service Account JSON file path")
+	rootCmd.PersistentFlags().String("account", "", "Google credentials JSON file path (service account or authorized user), defaults to Application Default Credentials")
+	rootCmd.PersistentFlags().String("account-email", "", "Google Service Account email, required for legacy .p12 keys")
 	rootCmd.PersistentFlags().String("token", "", "Access Token for Google API")
 
+	rootCmd.PersistentFlags().StringSlice("impersonate-service-account", nil, "Service Account to impersonate, comma separated list is treated as delegation chain ending with target account")
+	rootCmd.PersistentFlags().String("iam-url", "", "IAM Service Account Credentials API base URL")
+
+	rootCmd.PersistentFlags().StringSlice("scopes", nil, "OAuth scopes to request (defaults to Google Play Developer API scope)")
+	rootCmd.PersistentFlags().String("subject", "", "User to act as via domain-wide delegation (JWT 'sub' claim)")
+	rootCmd.PersistentFlags().String("token-uri", "", "OAuth 2.0 token endpoint, overrides token_uri of credentials file")
+
 	rootCmd.PersistentFlags().Bool("print-token", false, "Print Access Token for later usage")
+	rootCmd.PersistentFlags().String("token-cache", "", "Access Token cache file path (defaults to user's cache directory)")
+	rootCmd.PersistentFlags().Bool("no-token-cache", false, "Do not cache Access Tokens between invocations")
 
 	rootCmd.PersistentFlags().String("proxy", "", "HTTP Proxy")
 	rootCmd.PersistentFlags().Bool("proxy-insecure", false, "Skip TLS verification")
 
 	rootCmd.PersistentFlags().String("package-name", "", "Application Package Name")
+	rootCmd.PersistentFlags().Int("retries", 3, "Number of retries of failed idempotent API requests (POST requests such as edit insert, commit and image upload are never retried)")
+	rootCmd.PersistentFlags().String("api-url", "", "Base URL of Google APIs (e.g. fake server used in tests)")
+	rootCmd.PersistentFlags().String("cassette", "", "Path to file to record HTTP interactions to or replay them from")
+	rootCmd.PersistentFlags().String("cassette-mode", "replay", "Cassette mode: record or replay")
+	rootCmd.PersistentFlags().Bool("debug-http", false, "Log every HTTP request and response (credentials are redacted)")
 
 	viper.BindPFlag("account", rootCmd.PersistentFlags().Lookup("account"))
+	viper.BindPFlag("account-email", rootCmd.PersistentFlags().Lookup("account-email"))
+	viper.BindPFlag("token", rootCmd.PersistentFlags().Lookup("token"))
+	viper.BindPFlag("impersonate-service-account", rootCmd.PersistentFlags().Lookup("impersonate-service-account"))
+	viper.BindPFlag("iam-url", rootCmd.PersistentFlags().Lookup("iam-url"))
+	viper.BindPFlag("scopes", rootCmd.PersistentFlags().Lookup("scopes"))
+	viper.BindPFlag("subject", rootCmd.PersistentFlags().Lookup("subject"))
+	viper.BindPFlag("token-uri", rootCmd.PersistentFlags().Lookup("token-uri"))
 	viper.BindPFlag("print-token", rootCmd.PersistentFlags().Lookup("print-token"))
+	viper.BindPFlag("token-cache", rootCmd.PersistentFlags().Lookup("token-cache"))
+	viper.BindPFlag("no-token-cache", rootCmd.PersistentFlags().Lookup("no-token-cache"))
 	viper.BindPFlag("proxy", rootCmd.PersistentFlags().Lookup("proxy"))
 	viper.BindPFlag("proxy-insecure", rootCmd.PersistentFlags().Lookup("proxy-insecure"))
 	viper.BindPFlag("package-name", rootCmd.PersistentFlags().Lookup("package-name"))
+	viper.BindPFlag("retries", rootCmd.PersistentFlags().Lookup("retries"))
+	viper.BindPFlag("api-url", rootCmd.PersistentFlags().Lookup("api-url"))
+	viper.BindPFlag("cassette", rootCmd.PersistentFlags().Lookup("cassette"))
+	viper.BindPFlag("cassette-mode", rootCmd.PersistentFlags().Lookup("cassette-mode"))
+	viper.BindPFlag("debug-http", rootCmd.PersistentFlags().Lookup("debug-http"))
 }
 
 func Execute() {
 	err := rootCmd.Execute()
 	if err != nil {
 		fmt.Println(err)
-		os.Exit(1)
+		exit(1)
 	}
 }
@@ -0,0 +1,112 @@
+package command
+
+import (
+	"context"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/yurykabanov/google-play-edit/internal/pretty"
+)
+
+var (
+	// commandCtx is the context every command should derive its requests
+	// from, it carries command's span when tracing is enabled
+	commandCtx = context.Background()
+
+	commandSpan    trace.Span
+	tracerProvider *sdktrace.TracerProvider
+)
+
+// tracingEnabled follows standard OpenTelemetry environment variables,
+// tracing stays disabled unless OTLP exporter is configured.
+func tracingEnabled() bool {
+	if strings.EqualFold(os.Getenv("OTEL_SDK_DISABLED"), "true") {
+		return false
+	}
+
+	switch os.Getenv("OTEL_TRACES_EXPORTER") {
+	case "none":
+		return false
+	case "otlp":
+		return true
+	}
+
+	return os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") != "" || os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") != ""
+}
+
+func setupTracing(cmd *cobra.Command) {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	// Join trace of the pipeline we are running in, if any
+	ctx := otel.GetTextMapPropagator().Extract(context.Background(), propagation.MapCarrier{
+		"traceparent": os.Getenv("TRACEPARENT"),
+		"tracestate":  os.Getenv("TRACESTATE"),
+	})
+
+	if tracingEnabled() {
+		provider, err := newTracerProvider(ctx)
+		if err != nil {
+			pretty.Errorf("Unable to set up tracing, continuing without it: %s", err.Error())
+		} else {
+			tracerProvider = provider
+			otel.SetTracerProvider(provider)
+		}
+	}
+
+	commandCtx, commandSpan = otel.Tracer("github.com/yurykabanov/google-play-edit/internal/command").
+		Start(ctx, cmd.CommandPath())
+}
+
+func newTracerProvider(ctx context.Context) (*sdktrace.TracerProvider, error) {
+	// Exporter reads OTEL_EXPORTER_OTLP_* variables by itself
+	exporter, err := otlptracehttp.New(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Attributes from OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES take
+	// precedence over defaults
+	res, err := resource.New(ctx,
+		resource.WithAttributes(
+			attribute.String("service.name", appCommand),
+			attribute.String("service.version", Version),
+		),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return sdktrace.NewTracerProvider(sdktrace.WithBatcher(exporter), sdktrace.WithResource(res)), nil
+}
+
+// shutdownTracing ends command's span and flushes pending spans.
+func shutdownTracing(failed bool) {
+	if commandSpan != nil {
+		if failed {
+			commandSpan.SetStatus(codes.Error, "command failed")
+		}
+		commandSpan.End()
+		commandSpan = nil
+	}
+
+	if tracerProvider != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		tracerProvider.Shutdown(ctx)
+		tracerProvider = nil
+	}
+}
@@ -0,0 +1,106 @@
+package loader
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/yurykabanov/google-play-edit/pkg/play"
+)
+
+var imageExtensions = map[string]bool{
+	".png":  true,
+	".jpg":  true,
+	".jpeg": true,
+}
+
+// FindImages finds images of a language in directory laid out as follows:
+//
+//	<dir>/<lang>/<imageType>/*.png  for types with many images (screenshots)
+//	<dir>/<lang>/<imageType>.png    for types with a single image (icon etc.)
+//
+// Image types missing in the directory are absent in the result, so that their
+// remote images are left intact. Images are sorted by file name.
+func FindImages(dir string, lang string) (map[play.EditImageType][]string, error) {
+	langDir := filepath.Join(dir, lang)
+
+	entries, err := ioutil.ReadDir(langDir)
+	if os.IsNotExist(err) {
+		return map[play.EditImageType][]string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	images := make(map[play.EditImageType][]string)
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasPrefix(name, ".") {
+			continue
+		}
+
+		ext := filepath.Ext(name)
+		imageType := play.EditImageType(strings.TrimSuffix(name, ext))
+		if entry.IsDir() {
+			imageType = play.EditImageType(name)
+		}
+
+		if !imageType.Known() {
+			return nil, errors.New(fmt.Sprintf("unknown image type: %s", filepath.Join(langDir, name)))
+		}
+		if _, ok := images[imageType]; ok {
+			return nil, errors.New(fmt.Sprintf("more than one %s image for %s", imageType, lang))
+		}
+
+		if !entry.IsDir() {
+			if !imageType.Single() {
+				return nil, errors.New(fmt.Sprintf("%s must be a directory: %s", imageType, filepath.Join(langDir, name)))
+			}
+			if !imageExtensions[strings.ToLower(ext)] {
+				return nil, errors.New(fmt.Sprintf("unsupported image file: %s", filepath.Join(langDir, name)))
+			}
+
+			images[imageType] = []string{filepath.Join(langDir, name)}
+			continue
+		}
+
+		files, err := findImageFiles(filepath.Join(langDir, name))
+		if err != nil {
+			return nil, err
+		}
+		if imageType.Single() && len(files) > 1 {
+			return nil, errors.New(fmt.Sprintf("more than one %s image for %s", imageType, lang))
+		}
+
+		images[imageType] = files
+	}
+
+	return images, nil
+}
+
+func findImageFiles(dir string) ([]string, error) {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	files := []string{}
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		if !imageExtensions[strings.ToLower(filepath.Ext(entry.Name()))] {
+			return nil, errors.New(fmt.Sprintf("unsupported image file: %s", filepath.Join(dir, entry.Name())))
+		}
+
+		files = append(files, filepath.Join(dir, entry.Name()))
+	}
+	sort.Strings(files)
+
+	return files, nil
+}
@@ -0,0 +1,66 @@
+package loader
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yurykabanov/google-play-edit/pkg/play"
+)
+
+func touch(t *testing.T, dir string, names ...string) {
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, ioutil.WriteFile(path, nil, 0644))
+	}
+}
+
+func TestFindImages(t *testing.T) {
+	dir := t.TempDir()
+	touch(t, dir,
+		"en-US/phoneScreenshots/02.png",
+		"en-US/phoneScreenshots/01.jpg",
+		"en-US/icon.png",
+		"en-US/featureGraphic/graphic.jpeg",
+		"en-US/.DS_Store",
+	)
+
+	images, err := FindImages(dir, "en-US")
+	if assert.NoError(t, err) {
+		assert.Equal(t, map[play.EditImageType][]string{
+			play.EditImagePhoneScreenshots: {
+				filepath.Join(dir, "en-US/phoneScreenshots/01.jpg"),
+				filepath.Join(dir, "en-US/phoneScreenshots/02.png"),
+			},
+			play.EditImageIcon:           {filepath.Join(dir, "en-US/icon.png")},
+			play.EditImageFeatureGraphic: {filepath.Join(dir, "en-US/featureGraphic/graphic.jpeg")},
+		}, images)
+	}
+
+	// Missing language leaves all images intact
+	images, err = FindImages(dir, "de-DE")
+	if assert.NoError(t, err) {
+		assert.Empty(t, images)
+	}
+}
+
+func TestFindImages_Invalid(t *testing.T) {
+	for name, files := range map[string][]string{
+		"unknown type":        {"en-US/screenshots/01.png"},
+		"unsupported file":    {"en-US/phoneScreenshots/01.gif"},
+		"file of multi type":  {"en-US/phoneScreenshots.png"},
+		"two single images":   {"en-US/icon/a.png", "en-US/icon/b.png"},
+		"single file and dir": {"en-US/icon.png", "en-US/icon/a.png"},
+	} {
+		dir := t.TempDir()
+		touch(t, dir, files...)
+
+		_, err := FindImages(dir, "en-US")
+		assert.Error(t, err, name)
+	}
+}
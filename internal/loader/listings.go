@@ -8,73 +8,212 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 
 	"gopkg.in/yaml.v2"
 
 	"github.com/yurykabanov/google-play-edit/pkg/play"
+	"github.com/yurykabanov/google-play-edit/pkg/validate"
 )
 
 var (
 	ErrInsufficientColumns = errors.New(fmt.Sprintf("insufficient columns, csv must contain at least four following columns: Language, Title, ShortDescription, FullDescription"))
 )
 
-func LoadListingsFromFile(path string) ([]play.Listing, error) {
+// Listing is a listing loaded from file along with the fields it defines.
+type Listing struct {
+	play.Listing
+
+	// Fields present in the file, nil means all of them
+	Fields []play.ListingField
+
+	// Position of the listing in the file ("file:row" for CSV, "file[index]"
+	// otherwise) and keys (or columns) of its fields by field name
+	Source string
+	Keys   map[string]string
+}
+
+// Column and key names are matched ignoring case, spaces, dashes and
+// underscores, e.g. "Short Description" and "short_description" are the same
+var listingKeys = map[string]play.ListingField{
+	"title":            play.ListingTitle,
+	"fulldescription":  play.ListingFullDescription,
+	"shortdescription": play.ListingShortDescription,
+	"video":            play.ListingVideo,
+}
+
+func normalizeKey(key string) string {
+	return strings.ToLower(strings.NewReplacer(" ", "", "-", "", "_", "").Replace(key))
+}
+
+// LoadListingsFromFile loads listings from CSV, YAML or JSON file. Only fields
+// present in the file are meant to be updated: keys of YAML and JSON objects
+// and columns of CSV header (or first four columns of CSV without header).
+func LoadListingsFromFile(path string) ([]Listing, error) {
 	f, err := os.Open(path)
 	if err != nil {
 		return nil, err
 	}
 	defer f.Close()
 
-	var listings []play.Listing
+	var records []map[string]string
 
-	ext := filepath.Ext(path)
+	switch filepath.Ext(path) {
+	case ".csv":
+		return loadListingsFromCsv(path, f)
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(f)
+		err = dec.Decode(&records)
+	case ".json":
+		dec := json.NewDecoder(f)
+		err = dec.Decode(&records)
+	default:
+		return nil, errors.New(fmt.Sprintf("unknown format: %s", filepath.Ext(path)))
+	}
+	if err != nil {
+		return nil, err
+	}
 
-	if ext == ".csv" {
-		rdr := csv.NewReader(f)
-		for {
-			row, err := rdr.Read()
-			if err != nil {
-				if err == io.EOF {
-					return listings, nil
-				}
-				return nil, err
+	listings := make([]Listing, 0, len(records))
+
+	for i, record := range records {
+		listing := Listing{
+			Fields: []play.ListingField{},
+			Source: fmt.Sprintf("%s[%d]", path, i),
+			Keys:   make(map[string]string),
+		}
+
+		for key, value := range record {
+			if normalizeKey(key) == "language" {
+				listing.Language = value
+				listing.Keys[validate.LanguageKey] = key
+				continue
+			}
+
+			field, ok := listingKeys[normalizeKey(key)]
+			if !ok {
+				return nil, errors.New(fmt.Sprintf("unknown listing field: %s", key))
 			}
 
-			listing := play.Listing{}
+			listing.SetField(field, value)
+			listing.Fields = append(listing.Fields, field)
+			listing.Keys[string(field)] = key
+		}
+
+		sortFields(listing.Fields)
+		listings = append(listings, listing)
+	}
+
+	return listings, nil
+}
+
+func loadListingsFromCsv(path string, r io.Reader) ([]Listing, error) {
+	rdr := csv.NewReader(r)
+	rdr.FieldsPerRecord = -1
+
+	var listings []Listing
+	var columns []play.ListingField
+	var names []string
+
+	for {
+		row, err := rdr.Read()
+		if err != nil {
+			if err == io.EOF {
+				return listings, nil
+			}
+			return nil, err
+		}
 
+		// Header defines which fields are present
+		if columns == nil && len(row) > 0 && normalizeKey(row[0]) == "language" {
+			for _, name := range row[1:] {
+				field, ok := listingKeys[normalizeKey(name)]
+				if !ok {
+					return nil, errors.New(fmt.Sprintf("unknown listing column: %s", name))
+				}
+				columns = append(columns, field)
+			}
+			names = row
+			continue
+		}
+
+		if columns == nil {
 			if len(row) < 4 {
 				return nil, ErrInsufficientColumns
 			}
 
-			listing.Language = row[0]
-			listing.Title = row[1]
-			listing.ShortDescription = row[2]
-			listing.FullDescription = row[3]
-
+			columns = []play.ListingField{play.ListingTitle, play.ListingShortDescription, play.ListingFullDescription}
 			if len(row) == 5 {
-				listing.Video = row[4]
+				columns = append(columns, play.ListingVideo)
+			}
+
+			for i := range columns {
+				names = append(names, fmt.Sprintf("column %d", i+1))
 			}
+			names = append(names, fmt.Sprintf("column %d", len(columns)+1))
+		}
 
-			listings = append(listings, listing)
+		if len(row) != len(columns)+1 {
+			return nil, errors.New(fmt.Sprintf("expected %d columns, got %d", len(columns)+1, len(row)))
 		}
+
+		line, _ := rdr.FieldPos(0)
+
+		listing := Listing{
+			Listing: play.Listing{Language: row[0]},
+			Source:  fmt.Sprintf("%s:%d", path, line),
+			Keys:    map[string]string{validate.LanguageKey: names[0]},
+		}
+		for i, field := range columns {
+			listing.SetField(field, row[i+1])
+			listing.Fields = append(listing.Fields, field)
+			listing.Keys[string(field)] = names[i+1]
+		}
+		sortFields(listing.Fields)
+
+		listings = append(listings, listing)
 	}
+}
 
-	switch ext {
-	case ".yaml":
-	case ".yml":
-		dec := yaml.NewDecoder(f)
-		err = dec.Decode(&listings)
-	case ".json":
-		dec := json.NewDecoder(f)
-		err = dec.Decode(&listings)
-	default:
-		return nil, errors.New(fmt.Sprintf("unknown format: %s", ext))
+// ValidateListings normalizes listings and checks them against Play
+// requirements, violations point to rows (or keys) of the source file.
+// Warnings are returned separately and never make listings invalid.
+func ValidateListings(listings []Listing) ([]validate.Violation, error) {
+	var violations, warnings []validate.Violation
+
+	for i := range listings {
+		listing := &listings[i]
+
+		for _, violation := range validate.Listing(&listing.Listing, listing.Fields) {
+			violation.Path = listing.Source
+			if key, ok := listing.Keys[violation.Key]; ok {
+				violation.Key = key
+			}
+
+			if violation.Warning {
+				warnings = append(warnings, violation)
+			} else {
+				violations = append(violations, violation)
+			}
+		}
 	}
-	if err != nil {
-		return nil, err
+
+	if len(violations) > 0 {
+		return warnings, validate.Error{Violations: violations}
 	}
 
-	return listings, nil
+	return warnings, nil
+}
+
+// sortFields orders fields the same way as play.ListingFields.
+func sortFields(fields []play.ListingField) {
+	order := make(map[play.ListingField]int)
+	for i, field := range play.ListingFields {
+		order[field] = i
+	}
+
+	sort.Slice(fields, func(i, j int) bool { return order[fields[i]] < order[fields[j]] })
 }
 
 func FindImagesForLang(path, lang string) ([]string, error) {
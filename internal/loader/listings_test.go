@@ -0,0 +1,87 @@
+package loader
+
+import (
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yurykabanov/google-play-edit/pkg/play"
+	"github.com/yurykabanov/google-play-edit/pkg/validate"
+)
+
+func writeFile(t *testing.T, name string, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, ioutil.WriteFile(path, []byte(content), 0644))
+
+	return path
+}
+
+func TestLoadListingsFromFile(t *testing.T) {
+	title := play.Listing{Language: "en-US", Title: "Title"}
+
+	for name, content := range map[string]string{
+		"titles.csv":  "Language,Title\nen-US,Title\n",
+		"titles.json": `[{"language": "en-US", "title": "Title"}]`,
+		"titles.yaml": "- language: en-US\n  title: Title\n",
+	} {
+		listings, err := LoadListingsFromFile(writeFile(t, name, content))
+		if assert.NoError(t, err, name) && assert.Len(t, listings, 1, name) {
+			assert.Equal(t, title, listings[0].Listing, name)
+			assert.Equal(t, []play.ListingField{play.ListingTitle}, listings[0].Fields, name)
+		}
+	}
+
+	// CSV without header keeps video unless the fifth column is given
+	listings, err := LoadListingsFromFile(writeFile(t, "legacy.csv", "en-US,Title,Short,Full\n"))
+	if assert.NoError(t, err) {
+		assert.Equal(t, []play.ListingField{play.ListingTitle, play.ListingFullDescription, play.ListingShortDescription}, listings[0].Fields)
+		assert.Equal(t, "Short", listings[0].ShortDescription)
+	}
+
+	_, err = LoadListingsFromFile(writeFile(t, "unknown.json", `[{"language": "en-US", "subtitle": "Title"}]`))
+	assert.Error(t, err)
+}
+
+func TestValidateListings(t *testing.T) {
+	csvPath := writeFile(t, "listings.csv", "Language,Title,Video\nen-US,Title,\nxx-XX,"+strings.Repeat("x", 31)+",https://example.com/video\n")
+
+	listings, err := LoadListingsFromFile(csvPath)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	warnings, err := ValidateListings(listings)
+
+	// Unknown language doesn't make listing invalid
+	assert.Equal(t, []validate.Violation{
+		{Path: csvPath + ":3", Key: "Language", Message: `unknown language "xx-XX"`, Warning: true},
+	}, warnings)
+
+	var validationErr validate.Error
+	if assert.True(t, errors.As(err, &validationErr)) {
+		assert.Equal(t, []validate.Violation{
+			{Path: csvPath + ":3", Key: "Title", Message: "31 characters, at most 30 allowed"},
+			{Path: csvPath + ":3", Key: "Video", Message: `"https://example.com/video" is not a YouTube video URL`},
+		}, validationErr.Violations)
+	}
+
+	yamlPath := writeFile(t, "listings.yaml", "- language: en-US\n  title: Title\n- language: de-DE\n  short_description: "+strings.Repeat("x", 81)+"\n")
+
+	listings, err = LoadListingsFromFile(yamlPath)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	warnings, err = ValidateListings(listings)
+	assert.Empty(t, warnings)
+	if assert.True(t, errors.As(err, &validationErr)) {
+		assert.Equal(t, []validate.Violation{
+			{Path: yamlPath + "[1]", Key: "short_description", Message: "81 characters, at most 80 allowed"},
+		}, validationErr.Violations)
+	}
+}
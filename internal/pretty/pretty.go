@@ -9,6 +9,7 @@ import (
 	"github.com/logrusorgru/aurora"
 
 	"github.com/yurykabanov/google-play-edit/pkg/play"
+	"github.com/yurykabanov/google-play-edit/pkg/task"
 )
 
 func Errorf(format string, args ...interface{}) {
@@ -32,11 +33,47 @@ func Errorf(format string, args ...interface{}) {
 }
 
 func PrintEdit(edit *play.Edit) {
-	expiry, _ := strconv.ParseInt(edit.ExpiryTimeSeconds, 10, 64)
-
 	fmt.Println(aurora.Red("Edit").Bold())
 	fmt.Printf("%s: %s\n", aurora.Green("ID").Bold(), edit.Id)
-	fmt.Printf("%s: %s\n", aurora.Green("Expires at").Bold(), time.Unix(expiry, 0).Format(time.RFC3339))
+	fmt.Printf("%s: %s\n", aurora.Green("Expires at").Bold(), edit.Expiry.Format(time.RFC3339))
+}
+
+func PrintEditStatus(edit *play.Edit, now time.Time) {
+	PrintEdit(edit)
+
+	left := edit.TimeLeft(now)
+	if left <= 0 {
+		fmt.Printf("%s: %s\n", aurora.Green("Time left").Bold(), aurora.Red("expired"))
+		return
+	}
+
+	fmt.Printf("%s: %s\n", aurora.Green("Time left").Bold(), FormatDuration(left))
+}
+
+// Warnf prints highlighted warning.
+func Warnf(format string, args ...interface{}) {
+	fmt.Println(aurora.Black(fmt.Sprintf(" "+format+" ", args...)).BgBrown())
+}
+
+// FormatDuration formats duration with precision suitable for humans, e.g.
+// "6d 23h 59m".
+func FormatDuration(d time.Duration) string {
+	d = d.Round(time.Minute)
+
+	days := d / (24 * time.Hour)
+	d -= days * 24 * time.Hour
+	hours := d / time.Hour
+	d -= hours * time.Hour
+	minutes := d / time.Minute
+
+	if days > 0 {
+		return fmt.Sprintf("%dd %dh %dm", days, hours, minutes)
+	}
+	if hours > 0 {
+		return fmt.Sprintf("%dh %dm", hours, minutes)
+	}
+
+	return fmt.Sprintf("%dm", minutes)
 }
 
 func PrintListing(listing *play.Listing) {
@@ -50,3 +87,52 @@ func PrintListing(listing *play.Listing) {
 func PrintImage(image *play.Image) {
 	fmt.Printf("  - %s: %-20s [%-40s] %s\n", aurora.Green("Image"), image.Id, image.Sha1, aurora.Gray(image.Url))
 }
+
+func PrintPlan(plan *task.Plan) {
+	fmt.Printf("%s for %s\n", aurora.Red("Plan").Bold(), plan.PackageName)
+
+	if plan.Empty() {
+		fmt.Println(aurora.Gray("No changes, remote state matches desired one"))
+		return
+	}
+
+	for _, lang := range plan.DeleteLanguages {
+		fmt.Printf("  %s listing %s\n", aurora.Red("-"), lang)
+	}
+
+	for _, listing := range plan.Listings {
+		if update := listing.Update; update != nil {
+			sign, action := aurora.Brown("~"), "update"
+			if listing.Base == nil {
+				sign, action = aurora.Green("+"), "create"
+			}
+
+			fmt.Printf("  %s %s listing %s\n", sign, action, listing.Language)
+			for _, field := range update.Fields {
+				fmt.Printf("      %s: %q\n", field, update.Listing.Field(field))
+			}
+		}
+
+		for _, images := range listing.Images {
+			for _, ref := range images.Delete {
+				fmt.Printf("  %s %s/%s #%d [%s]\n", aurora.Red("-"), listing.Language, images.ImageType, ref.Position+1, ref.Sha1)
+			}
+			for _, upload := range images.Upload {
+				fmt.Printf("  %s %s/%s %s [%s]\n", aurora.Green("+"), listing.Language, images.ImageType, upload.Path, upload.Sha1)
+			}
+		}
+	}
+}
+
+func PrintDryRun(calls []play.DryRunCall) {
+	fmt.Printf("%s: %d change(s) would be made\n", aurora.Red("Dry run").Bold(), len(calls))
+
+	for _, call := range calls {
+		target := call.Language
+		if call.ImageType != "" {
+			target += "/" + string(call.ImageType)
+		}
+
+		fmt.Printf("  %-18s %-28s %s\n", aurora.Brown(call.Operation), target, aurora.Gray(call.Detail))
+	}
+}
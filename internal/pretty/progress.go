@@ -0,0 +1,136 @@
+package pretty
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/logrusorgru/aurora"
+)
+
+const (
+	progressBarWidth = 30
+
+	// Plain output is printed no more often than this
+	progressLogInterval = 5 * time.Second
+)
+
+// Progress renders aggregated progress of uploads: a bar redrawn in place
+// when output is a terminal and periodic log lines otherwise.
+type Progress struct {
+	out io.Writer
+	tty bool
+
+	mu        sync.Mutex
+	uploads   map[string]*upload
+	doneFiles int
+	doneBytes int64
+}
+
+type upload struct {
+	sent    int64
+	total   int64
+	done    bool
+	lastLog time.Time
+}
+
+func NewProgress(out *os.File) *Progress {
+	return &Progress{out: out, tty: IsTerminal(out), uploads: make(map[string]*upload)}
+}
+
+// IsTerminal reports whether file is a character device, i.e. a terminal.
+func IsTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// Update reports progress of upload identified by name. Uploads could
+// overlap, and a finished upload is counted once even if it is sent again
+// (e.g. retried).
+func (p *Progress) Update(name string, sent int64, total int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	u, ok := p.uploads[name]
+	if !ok {
+		u = &upload{}
+		p.uploads[name] = u
+	}
+	if u.done {
+		return
+	}
+
+	u.sent = sent
+	u.total = total
+
+	if sent >= total {
+		u.done = true
+		p.doneFiles++
+		p.doneBytes += total
+		p.render(name, u)
+		return
+	}
+
+	p.render(name, u)
+}
+
+func (p *Progress) render(name string, u *upload) {
+	if p.tty {
+		p.renderBar(name, u)
+		return
+	}
+
+	if !u.done && time.Since(u.lastLog) < progressLogInterval {
+		return
+	}
+	u.lastLog = time.Now()
+
+	if u.done {
+		fmt.Fprintf(p.out, "Uploaded %s (%s), %d files / %s in total\n",
+			name, formatBytes(u.total), p.doneFiles, formatBytes(p.doneBytes))
+	} else {
+		fmt.Fprintf(p.out, "Uploading %s: %s of %s\n", name, formatBytes(u.sent), formatBytes(u.total))
+	}
+}
+
+func (p *Progress) renderBar(name string, u *upload) {
+	ratio := 1.0
+	if u.total > 0 {
+		ratio = float64(u.sent) / float64(u.total)
+	}
+
+	filled := int(ratio * progressBarWidth)
+	bar := strings.Repeat("=", filled) + strings.Repeat(" ", progressBarWidth-filled)
+
+	fmt.Fprintf(p.out, "\r\033[K[%s] %3.0f%% %s %s/%s (%d files, %s uploaded)",
+		aurora.Green(bar), ratio*100, name,
+		formatBytes(u.sent), formatBytes(u.total),
+		p.doneFiles, formatBytes(p.doneBytes))
+
+	if u.done {
+		fmt.Fprintln(p.out)
+	}
+}
+
+func formatBytes(n int64) string {
+	const unit = 1024
+
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,28 @@
+package pretty
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProgress_Update(t *testing.T) {
+	var out bytes.Buffer
+	p := &Progress{out: &out, uploads: make(map[string]*upload)}
+
+	// Overlapping uploads
+	p.Update("a.png", 0, 10)
+	p.Update("b.png", 0, 20)
+	p.Update("a.png", 10, 10)
+	p.Update("b.png", 20, 20)
+
+	// Retry of a finished upload is not counted again
+	p.Update("a.png", 0, 10)
+	p.Update("a.png", 10, 10)
+
+	assert.Equal(t, 2, p.doneFiles)
+	assert.Equal(t, int64(30), p.doneBytes)
+	assert.Contains(t, out.String(), "Uploaded a.png (10 B), 1 files / 10 B in total")
+	assert.Contains(t, out.String(), "Uploaded b.png (20 B), 2 files / 30 B in total")
+}
@@ -2,39 +2,20 @@ package play
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
 	"io"
 	"net/http"
+	"strings"
 )
 
-const apiBaseUrl = "https://www.googleapis.com/androidpublisher/v3/applications/%s"
-
-type ApiError struct {
-	ErrorDefinition struct {
-		Code    int                      `json:"code"`
-		Errors  []map[string]interface{} `json:"errors"`
-		Message string                   `json:"message"`
-	} `json:"error"`
-}
-
-func decodeApiErrorResponse(decoder *json.Decoder) error {
-	var apiError ApiError
-
-	err := decoder.Decode(&apiError)
-	if err != nil {
-		return err
-	}
-
-	return apiError
-}
-
-func (err ApiError) Error() string {
-	return fmt.Sprintf("api error %d: %s", err.ErrorDefinition.Code, err.ErrorDefinition.Message)
-}
+const (
+	googleApisUrl = "https://www.googleapis.com"
+	apiBaseUrl    = googleApisUrl + "/androidpublisher/v3/applications/%s"
+)
 
 type Api struct {
-	client *http.Client
+	client     *http.Client
+	middleware []Middleware
+	baseUrl    string
 
 	Edits    EditsApi
 	Listings EditListingsApi
@@ -49,6 +30,22 @@ func WithApiHttpClient(client *http.Client) ApiClientOption {
 	}
 }
 
+// WithApiMiddleware adds middleware to every API request, middleware given
+// first is executed first.
+func WithApiMiddleware(middleware ...Middleware) ApiClientOption {
+	return func(c *Api) {
+		c.middleware = append(c.middleware, middleware...)
+	}
+}
+
+// WithApiBaseUrl makes client send requests to given server instead of
+// https://www.googleapis.com, e.g. to a local stand-in.
+func WithApiBaseUrl(baseUrl string) ApiClientOption {
+	return func(c *Api) {
+		c.baseUrl = strings.TrimRight(baseUrl, "/")
+	}
+}
+
 func NewApi(opts ...ApiClientOption) *Api {
 	api := &Api{}
 
@@ -60,15 +57,18 @@ func NewApi(opts ...ApiClientOption) *Api {
 		api.client = defaultHttpClient()
 	}
 
+	executor := newRequestExecutor(api.client, api.middleware)
+	executor.baseUrl = api.baseUrl
+
 	return &Api{
 		Edits: &editsApi{
-			client: api.client,
+			executor: executor,
 		},
 		Listings: &editListingsApi{
-			client: api.client,
+			executor: executor,
 		},
 		Images: &editImagesApi{
-			client: api.client,
+			executor: executor,
 		},
 	}
 }
@@ -86,6 +86,7 @@ type EditListingsApi interface {
 	DeleteAll(ctx context.Context, token *AccessToken, packageName string, editId string) error
 	Get(ctx context.Context, token *AccessToken, packageName string, editId string, lang string) (*Listing, error)
 	List(ctx context.Context, token *AccessToken, packageName string, editId string) ([]Listing, error)
+	Patch(ctx context.Context, token *AccessToken, packageName string, editId string, listing *Listing, fields []ListingField) (*Listing, error)
 	Update(ctx context.Context, token *AccessToken, packageName string, editId string, listing *Listing) (*Listing, error)
 }
 
@@ -93,5 +94,5 @@ type EditImagesApi interface {
 	Delete(ctx context.Context, token *AccessToken, packageName string, editId string, lang string, imageType EditImageType, imageId string) error
 	DeleteAll(ctx context.Context, token *AccessToken, packageName string, editId string, lang string, imageType EditImageType) ([]Image, error)
 	List(ctx context.Context, token *AccessToken, packageName string, editId string, lang string, imageType EditImageType) ([]Image, error)
-	Upload(ctx context.Context, token *AccessToken, packageName string, editId string, lang string, imageType EditImageType, imageReader io.ReadSeeker) (*Image, error)
+	Upload(ctx context.Context, token *AccessToken, packageName string, editId string, lang string, imageType EditImageType, imageReader io.ReadSeeker, opts ...UploadOption) (*Image, error)
 }
@@ -4,27 +4,39 @@ import (
 	"bytes"
 	"context"
 	"crypto/rsa"
-	"crypto/x509"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
-	"encoding/pem"
 	"fmt"
 	"net/http"
 	"net/url"
+	"strings"
 	"time"
 
 	"github.com/dgrijalva/jwt-go"
+	"go.opentelemetry.io/otel/attribute"
 )
 
-const authUrl = "https://www.googleapis.com/oauth2/v4/token"
+const (
+	authUrl = "https://www.googleapis.com/oauth2/v4/token"
 
-type InvalidPrivateKeyTypeError struct {
-	privateKey interface{}
+	ServiceAccountType  = "service_account"
+	AuthorizedUserType  = "authorized_user"
+	ExternalAccountType = "external_account"
+
+	androidPublisherScope = "https://www.googleapis.com/auth/androidpublisher"
+)
+
+type UnsupportedCredentialsTypeError struct {
+	Type string
 }
 
-func (err InvalidPrivateKeyTypeError) Error() string {
-	return fmt.Sprintf("invalid private key type: %T", err.privateKey)
+func (err UnsupportedCredentialsTypeError) Error() string {
+	return fmt.Sprintf("unsupported credentials type '%s'", err.Type)
 }
 
+// ServiceAccount describes Google credentials file. Despite its name it also
+// covers "authorized_user" files produced by gcloud, Type selects the flow.
 type ServiceAccount struct {
 	Type                    string `json:"type"`
 	ProjectId               string `json:"project_id"`
@@ -36,12 +48,52 @@ type ServiceAccount struct {
 	TokenUri                string `json:"token_uri"`
 	AuthProviderX509CertUrl string `json:"auth_provider_x509_cert_url"`
 	ClientX509CertUrl       string `json:"client_x509_cert_url"`
+
+	// authorized_user fields
+	ClientSecret string `json:"client_secret"`
+	RefreshToken string `json:"refresh_token"`
+
+	// external_account fields
+	Audience                       string            `json:"audience"`
+	SubjectTokenType               string            `json:"subject_token_type"`
+	TokenUrl                       string            `json:"token_url"`
+	ServiceAccountImpersonationUrl string            `json:"service_account_impersonation_url"`
+	CredentialSource               *CredentialSource `json:"credential_source"`
+}
+
+// identity returns a stable value identifying the principal behind credentials
+// without exposing any secrets.
+func (account *ServiceAccount) identity() string {
+	switch account.Type {
+	case AuthorizedUserType:
+		sum := sha256.Sum256([]byte(account.RefreshToken))
+		return AuthorizedUserType + ":" + hex.EncodeToString(sum[:8])
+	case ExternalAccountType:
+		return ExternalAccountType + ":" + account.Audience + ":" + account.ServiceAccountImpersonationUrl
+	}
+
+	return account.ClientEmail
 }
 
 type AccessToken struct {
-	AccessToken string `json:"access_token"`
-	ExpiresIn   int    `json:"expires_in"`
-	TokenType   string `json:"token_type"`
+	AccessToken string    `json:"access_token"`
+	ExpiresIn   int       `json:"expires_in"`
+	TokenType   string    `json:"token_type"`
+	Expiry      time.Time `json:"expiry,omitempty"`
+}
+
+// ValidFor reports whether token is still valid for at least given duration.
+// Tokens without known expiry are always considered valid.
+func (token *AccessToken) ValidFor(d time.Duration) bool {
+	if token == nil || token.AccessToken == "" {
+		return false
+	}
+
+	if token.Expiry.IsZero() {
+		return true
+	}
+
+	return time.Now().Add(d).Before(token.Expiry)
 }
 
 type AuthError struct {
@@ -54,7 +106,22 @@ func (err AuthError) Error() string {
 }
 
 type AuthClient struct {
-	client *http.Client
+	client     *http.Client
+	middleware []Middleware
+	doer       Doer
+
+	cache     TokenCache
+	cacheWarn func(err error)
+
+	stsUrl string
+	iamUrl string
+
+	scopes   []string
+	subject  string
+	tokenUrl string
+
+	impersonateTarget string
+	delegates         []string
 }
 
 type AuthClientOption func(c *AuthClient)
@@ -65,6 +132,48 @@ func WithAuthHttpClient(client *http.Client) AuthClientOption {
 	}
 }
 
+// WithAuthMiddleware adds middleware to every request made during
+// authentication, middleware given first is executed first.
+func WithAuthMiddleware(middleware ...Middleware) AuthClientOption {
+	return func(c *AuthClient) {
+		c.middleware = append(c.middleware, middleware...)
+	}
+}
+
+// WithAuthTokenCache makes client reuse previously issued tokens until they
+// are close to expiry. Cache is best-effort, its errors never fail
+// authentication and are only passed to warn (which may be nil).
+func WithAuthTokenCache(cache TokenCache, warn func(err error)) AuthClientOption {
+	return func(c *AuthClient) {
+		c.cache = cache
+		c.cacheWarn = warn
+	}
+}
+
+// WithAuthScopes sets OAuth scopes requested for access tokens, by default
+// only Google Play Developer API scope is requested.
+func WithAuthScopes(scopes ...string) AuthClientOption {
+	return func(c *AuthClient) {
+		c.scopes = scopes
+	}
+}
+
+// WithAuthSubject sets "sub" claim of service account's JWT, i.e. the user
+// to impersonate via domain-wide delegation.
+func WithAuthSubject(subject string) AuthClientOption {
+	return func(c *AuthClient) {
+		c.subject = subject
+	}
+}
+
+// WithAuthTokenUrl overrides OAuth 2.0 token endpoint (which is also the
+// audience of service account's JWT), it takes precedence over token_uri.
+func WithAuthTokenUrl(tokenUrl string) AuthClientOption {
+	return func(c *AuthClient) {
+		c.tokenUrl = tokenUrl
+	}
+}
+
 func NewAuthClient(opts ...AuthClientOption) *AuthClient {
 	auth := &AuthClient{}
 
@@ -76,25 +185,127 @@ func NewAuthClient(opts ...AuthClientOption) *AuthClient {
 		auth.client = defaultHttpClient()
 	}
 
+	auth.doer = chainMiddleware(auth.client, auth.middleware)
+
+	if auth.iamUrl == "" {
+		auth.iamUrl = iamCredentialsUrl
+	}
+
+	if len(auth.scopes) == 0 {
+		auth.scopes = []string{androidPublisherScope}
+	}
+
 	return auth
 }
 
-func (auth *AuthClient) Authenticate(ctx context.Context, account *ServiceAccount) (*AccessToken, error) {
-	pkey, err := auth.rsaPrivateKey(account)
+func (auth *AuthClient) Authenticate(ctx context.Context, account *ServiceAccount) (_ *AccessToken, err error) {
+	ctx, span := StartSpan(ctx, "play.auth.authenticate",
+		attribute.String("play.credentials_type", account.Type),
+		attribute.Bool("play.impersonation", auth.impersonateTarget != ""),
+	)
+	defer func() { EndSpan(span, err) }()
+
+	identity := account.identity()
+	if auth.subject != "" {
+		identity += " as " + auth.subject
+	}
+	if auth.impersonateTarget != "" {
+		chain := append(append([]string(nil), auth.delegates...), auth.impersonateTarget)
+		identity += " -> " + strings.Join(chain, " -> ")
+	}
+
+	// Tokens issued by different endpoints are never mixed up either
+	identity += " at " + auth.tokenUrlFor(account)
+
+	cacheKey := TokenCacheKey(identity, auth.scopes...)
+
+	if auth.cache != nil {
+		token, err := auth.cache.Get(cacheKey)
+		if err != nil {
+			auth.warnCache(err)
+		}
+		if token != nil {
+			span.SetAttributes(attribute.Bool("play.token_cache_hit", true))
+			return token, nil
+		}
+	}
+
+	token, err := auth.issueToken(ctx, account, auth.scopes)
 	if err != nil {
 		return nil, err
 	}
 
-	signedJwtToken, err := auth.makeJwtToken(account).SignedString(pkey)
+	if auth.cache != nil {
+		if err := auth.cache.Put(cacheKey, token); err != nil {
+			auth.warnCache(err)
+		}
+	}
+
+	return token, nil
+}
+
+func (auth *AuthClient) warnCache(err error) {
+	if auth.cacheWarn != nil {
+		auth.cacheWarn(err)
+	}
+}
+
+func (auth *AuthClient) issueToken(ctx context.Context, account *ServiceAccount, scopes []string) (*AccessToken, error) {
+	if auth.impersonateTarget == "" {
+		return auth.requestToken(ctx, account, scopes)
+	}
+
+	source, err := auth.requestToken(ctx, account, []string{cloudPlatformScope})
 	if err != nil {
 		return nil, err
 	}
 
-	req, err := auth.makeAuthRequest(signedJwtToken)
+	return auth.impersonate(ctx, source, scopes)
+}
+
+func (auth *AuthClient) requestToken(ctx context.Context, account *ServiceAccount, scopes []string) (*AccessToken, error) {
+	var params *url.Values
+	var err error
+
+	switch account.Type {
+	case ServiceAccountType, "":
+		params, err = auth.makeJwtBearerValues(account, scopes)
+	case AuthorizedUserType:
+		params = auth.makeRefreshTokenValues(account)
+	case ExternalAccountType:
+		return auth.externalAccountToken(ctx, account, scopes)
+	default:
+		err = UnsupportedCredentialsTypeError{Type: account.Type}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return auth.exchangeToken(ctx, auth.tokenUrlFor(account), params)
+}
+
+func (auth *AuthClient) tokenUrlFor(account *ServiceAccount) string {
+	if auth.tokenUrl != "" {
+		return auth.tokenUrl
+	}
+
+	if account.TokenUri != "" {
+		return account.TokenUri
+	}
+
+	return authUrl
+}
+
+// exchangeToken posts form to OAuth 2.0 token endpoint and decodes issued token.
+func (auth *AuthClient) exchangeToken(ctx context.Context, tokenUrl string, params *url.Values) (*AccessToken, error) {
+	req, err := auth.makeAuthRequest(tokenUrl, params)
+	if err != nil {
+		return nil, err
+	}
 
 	req = req.WithContext(ctx)
 
-	resp, err := auth.client.Do(req)
+	resp, err := auth.doer.Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -109,27 +320,52 @@ func (auth *AuthClient) Authenticate(ctx context.Context, account *ServiceAccoun
 	return auth.decodeAccessToken(dec)
 }
 
-func (auth *AuthClient) makeJwtToken(account *ServiceAccount) *jwt.Token {
-	return jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+func (auth *AuthClient) makeJwtToken(account *ServiceAccount, scopes []string) *jwt.Token {
+	claims := jwt.MapClaims{
 		"iss":   account.ClientEmail,
-		"scope": "https://www.googleapis.com/auth/androidpublisher",
-		"aud":   authUrl,
+		"scope": strings.Join(scopes, " "),
+		"aud":   auth.tokenUrlFor(account),
 		"iat":   time.Now().Unix(),
 		"exp":   time.Now().Add(1 * time.Hour).Unix(),
-	})
+	}
+
+	if auth.subject != "" {
+		claims["sub"] = auth.subject
+	}
+
+	return jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
 }
 
-func (auth *AuthClient) makeAuthRequestValues(signedJwtToken string) *url.Values {
+func (auth *AuthClient) makeJwtBearerValues(account *ServiceAccount, scopes []string) (*url.Values, error) {
+	pkey, err := auth.rsaPrivateKey(account)
+	if err != nil {
+		return nil, err
+	}
+
+	signedJwtToken, err := auth.makeJwtToken(account, scopes).SignedString(pkey)
+	if err != nil {
+		return nil, err
+	}
+
 	params := url.Values{}
 	params.Set("grant_type", "urn:ietf:params:oauth:grant-type:jwt-bearer")
 	params.Set("assertion", signedJwtToken)
 
+	return &params, nil
+}
+
+func (auth *AuthClient) makeRefreshTokenValues(account *ServiceAccount) *url.Values {
+	params := url.Values{}
+	params.Set("grant_type", "refresh_token")
+	params.Set("client_id", account.ClientId)
+	params.Set("client_secret", account.ClientSecret)
+	params.Set("refresh_token", account.RefreshToken)
+
 	return &params
 }
 
-func (auth *AuthClient) makeAuthRequest(signedJwtToken string) (*http.Request, error) {
-	req, err := http.NewRequest(http.MethodPost, authUrl,
-		bytes.NewBufferString(auth.makeAuthRequestValues(signedJwtToken).Encode()))
+func (auth *AuthClient) makeAuthRequest(tokenUrl string, params *url.Values) (*http.Request, error) {
+	req, err := http.NewRequest(http.MethodPost, tokenUrl, bytes.NewBufferString(params.Encode()))
 	if err != nil {
 		return nil, err
 	}
@@ -140,19 +376,7 @@ func (auth *AuthClient) makeAuthRequest(signedJwtToken string) (*http.Request, e
 }
 
 func (auth *AuthClient) rsaPrivateKey(account *ServiceAccount) (*rsa.PrivateKey, error) {
-	block, _ := pem.Decode([]byte(account.PrivateKey))
-
-	pkey, err := x509.ParsePKCS8PrivateKey(block.Bytes)
-	if err != nil {
-		return nil, err
-	}
-
-	key, ok := pkey.(*rsa.PrivateKey)
-	if !ok {
-		return nil, InvalidPrivateKeyTypeError{privateKey: key}
-	}
-
-	return key, nil
+	return ParsePrivateKey([]byte(account.PrivateKey))
 }
 
 func (auth *AuthClient) decodeError(decoder *json.Decoder) error {
@@ -174,5 +398,9 @@ func (auth *AuthClient) decodeAccessToken(decoder *json.Decoder) (*AccessToken,
 		return nil, err
 	}
 
+	if accessToken.ExpiresIn > 0 {
+		accessToken.Expiry = time.Now().Add(time.Duration(accessToken.ExpiresIn) * time.Second)
+	}
+
 	return &accessToken, nil
 }
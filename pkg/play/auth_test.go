@@ -0,0 +1,62 @@
+package play
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthClient_AuthenticateServiceAccount(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	assert.Nil(t, err)
+
+	var claims jwt.MapClaims
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Nil(t, r.ParseForm())
+
+		claims = jwt.MapClaims{}
+		_, err := jwt.ParseWithClaims(r.PostForm.Get("assertion"), claims, func(token *jwt.Token) (interface{}, error) {
+			return &key.PublicKey, nil
+		})
+		assert.Nil(t, err)
+
+		w.Write([]byte(`{"access_token": "token", "token_type": "Bearer", "expires_in": 3600}`))
+	}))
+	defer server.Close()
+
+	account := &ServiceAccount{
+		Type:        ServiceAccountType,
+		ClientEmail: "svc@example.iam.gserviceaccount.com",
+		PrivateKey:  string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})),
+		TokenUri:    server.URL + "/token",
+	}
+
+	// By default token_uri of account is used as audience
+	_, err = NewAuthClient(WithAuthHttpClient(server.Client())).Authenticate(context.Background(), account)
+	assert.Nil(t, err)
+	assert.Equal(t, server.URL+"/token", claims["aud"])
+	assert.Equal(t, androidPublisherScope, claims["scope"])
+	assert.Nil(t, claims["sub"])
+
+	auth := NewAuthClient(
+		WithAuthHttpClient(server.Client()),
+		WithAuthTokenUrl(server.URL+"/private"),
+		WithAuthScopes("https://www.googleapis.com/auth/playdeveloperreporting", androidPublisherScope),
+		WithAuthSubject("user@example.com"),
+	)
+
+	_, err = auth.Authenticate(context.Background(), account)
+	assert.Nil(t, err)
+	assert.Equal(t, server.URL+"/private", claims["aud"])
+	assert.Equal(t, "https://www.googleapis.com/auth/playdeveloperreporting "+androidPublisherScope, claims["scope"])
+	assert.Equal(t, "user@example.com", claims["sub"])
+}
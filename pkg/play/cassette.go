@@ -0,0 +1,306 @@
+package play
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"unicode/utf8"
+)
+
+type CassetteMode string
+
+const (
+	// CassetteRecord sends requests to the server and saves interactions
+	CassetteRecord CassetteMode = "record"
+
+	// CassetteReplay serves saved interactions and never touches network
+	CassetteReplay CassetteMode = "replay"
+)
+
+// Response headers that are stored in cassettes, others are dropped
+var cassetteHeaders = []string{"Content-Type", "Location", "Retry-After"}
+
+// Interaction is a single request and its response as saved in cassette.
+// Credentials are scrubbed from both of them before saving.
+type Interaction struct {
+	Request struct {
+		Method   string `json:"method"`
+		Url      string `json:"url"`
+		BodyHash string `json:"bodyHash"`
+		Body     string `json:"body,omitempty"`
+	} `json:"request"`
+
+	Response struct {
+		Status       int         `json:"status"`
+		Header       http.Header `json:"header,omitempty"`
+		Body         string      `json:"body,omitempty"`
+		BodyIsBase64 bool        `json:"bodyIsBase64,omitempty"`
+	} `json:"response"`
+}
+
+type CassetteMissError struct {
+	Method string
+	Path   string
+}
+
+func (err CassetteMissError) Error() string {
+	return fmt.Sprintf("no recorded interaction for %s %s", err.Method, err.Path)
+}
+
+// Cassette is http.RoundTripper recording interactions into a fixture file or
+// replaying them from it. Requests are matched by method, path and hash of
+// scrubbed body; identical requests are replayed in the order of recording.
+type Cassette struct {
+	path      string
+	mode      CassetteMode
+	transport http.RoundTripper
+
+	mu           sync.Mutex
+	interactions []*Interaction
+	used         []bool
+}
+
+// NewCassette opens cassette file. In record mode requests are sent via
+// transport (http.DefaultTransport if nil) and file is overwritten after
+// every interaction.
+func NewCassette(path string, mode CassetteMode, transport http.RoundTripper) (*Cassette, error) {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	cassette := &Cassette{path: path, mode: mode, transport: transport}
+
+	switch mode {
+	case CassetteRecord:
+		return cassette, nil
+
+	case CassetteReplay:
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		err = json.Unmarshal(data, &cassette.interactions)
+		if err != nil {
+			return nil, fmt.Errorf("malformed cassette %s: %w", path, err)
+		}
+		cassette.used = make([]bool, len(cassette.interactions))
+
+		return cassette, nil
+	}
+
+	return nil, fmt.Errorf("unknown cassette mode '%s'", mode)
+}
+
+// Client returns HTTP client using cassette as transport.
+func (cassette *Cassette) Client() *http.Client {
+	return &http.Client{Transport: cassette}
+}
+
+func (cassette *Cassette) RoundTrip(req *http.Request) (*http.Response, error) {
+	req, body, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	contentType := req.Header.Get("Content-Type")
+	bodyHash := scrubbedBodyHash(contentType, body)
+
+	if cassette.mode == CassetteReplay {
+		// Nothing is sent, but RoundTripper has to close request body anyway
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return cassette.replay(req, bodyHash)
+	}
+
+	resp, err := cassette.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(respBody))
+
+	interaction := &Interaction{}
+	interaction.Request.Method = req.Method
+	interaction.Request.Url = redactUrl(req.URL)
+	interaction.Request.BodyHash = bodyHash
+	if mediaType, _, _ := mime.ParseMediaType(contentType); !isBinaryMediaType(mediaType) {
+		interaction.Request.Body = scrubBody(contentType, body)
+	}
+
+	interaction.Response.Status = resp.StatusCode
+	for _, name := range cassetteHeaders {
+		if value := resp.Header.Get(name); value != "" {
+			if interaction.Response.Header == nil {
+				interaction.Response.Header = make(http.Header)
+			}
+			interaction.Response.Header.Set(name, value)
+		}
+	}
+	if utf8.Valid(respBody) {
+		interaction.Response.Body = RedactSecrets(string(respBody))
+	} else {
+		interaction.Response.Body = base64.StdEncoding.EncodeToString(respBody)
+		interaction.Response.BodyIsBase64 = true
+	}
+
+	err = cassette.record(interaction)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+func (cassette *Cassette) replay(req *http.Request, bodyHash string) (*http.Response, error) {
+	cassette.mu.Lock()
+	defer cassette.mu.Unlock()
+
+	for i, interaction := range cassette.interactions {
+		if cassette.used[i] || !interaction.matches(req, bodyHash) {
+			continue
+		}
+		cassette.used[i] = true
+
+		body := []byte(interaction.Response.Body)
+		if interaction.Response.BodyIsBase64 {
+			var err error
+			body, err = base64.StdEncoding.DecodeString(interaction.Response.Body)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		header := make(http.Header)
+		for name, values := range interaction.Response.Header {
+			header[name] = append([]string(nil), values...)
+		}
+
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", interaction.Response.Status, http.StatusText(interaction.Response.Status)),
+			StatusCode:    interaction.Response.Status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header,
+			Body:          ioutil.NopCloser(bytes.NewReader(body)),
+			ContentLength: int64(len(body)),
+			Request:       req,
+		}, nil
+	}
+
+	return nil, CassetteMissError{Method: req.Method, Path: req.URL.Path}
+}
+
+func (interaction *Interaction) matches(req *http.Request, bodyHash string) bool {
+	if interaction.Request.Method != req.Method || interaction.Request.BodyHash != bodyHash {
+		return false
+	}
+
+	recordedReq, err := http.NewRequest(interaction.Request.Method, interaction.Request.Url, nil)
+	if err != nil {
+		return false
+	}
+
+	return recordedReq.URL.Path == req.URL.Path
+}
+
+func (cassette *Cassette) record(interaction *Interaction) error {
+	cassette.mu.Lock()
+	defer cassette.mu.Unlock()
+
+	cassette.interactions = append(cassette.interactions, interaction)
+
+	data, err := json.MarshalIndent(cassette.interactions, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(cassette.path), 0755)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(cassette.path, data, 0644)
+}
+
+// readRequestBody returns request body along with request to send further.
+// Body is taken from GetBody when possible, otherwise it is consumed and the
+// returned request is a clone with a fresh body, req itself is never changed.
+func readRequestBody(req *http.Request) (*http.Request, []byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return req, nil, nil
+	}
+
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, nil, err
+		}
+		defer body.Close()
+
+		data, err := ioutil.ReadAll(body)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return req, data, nil
+	}
+
+	data, err := ioutil.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	clone := req.Clone(req.Context())
+	clone.Body = ioutil.NopCloser(bytes.NewReader(data))
+	clone.GetBody = func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(data)), nil
+	}
+
+	return clone, data, nil
+}
+
+// scrubBody removes credentials (signed assertions, tokens, keys) from body.
+func scrubBody(contentType string, body []byte) string {
+	text := RedactSecrets(string(body))
+
+	if mediaType, _, _ := mime.ParseMediaType(contentType); mediaType == "application/x-www-form-urlencoded" {
+		text = redactForm(text)
+	}
+
+	return text
+}
+
+// scrubbedBodyHash hashes body without credentials, so that e.g. freshly
+// signed JWT assertions still match the recorded ones.
+func scrubbedBodyHash(contentType string, body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+
+	if mediaType, _, _ := mime.ParseMediaType(contentType); !isBinaryMediaType(mediaType) {
+		body = []byte(scrubBody(contentType, body))
+	}
+
+	sum := sha256.Sum256(body)
+
+	return hex.EncodeToString(sum[:])
+}
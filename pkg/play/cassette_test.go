@@ -0,0 +1,161 @@
+package play
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCassette_RecordAndReplay(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+
+	var edits int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Set-Cookie", "session=secret")
+
+		if r.URL.Path == "/token" {
+			w.Write([]byte(`{"access_token": "ya29.secret-token", "token_type": "Bearer", "expires_in": 3600}`))
+			return
+		}
+
+		if atomic.AddInt32(&edits, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(`{"error": {"code": 503, "message": "Backend Error"}}`))
+			return
+		}
+		w.Write([]byte(`{"id": "edit-1", "expiryTimeSeconds": "1600000000"}`))
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "fixtures", "insert.json")
+
+	account := &ServiceAccount{
+		Type:        ServiceAccountType,
+		ClientEmail: "svc@example.iam.gserviceaccount.com",
+		PrivateKey:  string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})),
+		TokenUri:    server.URL + "/token",
+	}
+
+	session := func(cassette *Cassette) (*Edit, error) {
+		client := cassette.Client()
+
+		token, err := NewAuthClient(WithAuthHttpClient(client)).Authenticate(context.Background(), account)
+		if err != nil {
+			return nil, err
+		}
+
+		api := NewApi(
+			WithApiHttpClient(client),
+			WithApiBaseUrl(server.URL),
+			WithApiMiddleware(RetryMiddleware(2, 0)),
+		)
+
+		return api.Edits.Insert(AllowPostRetry(context.Background()), token, "com.example.project")
+	}
+
+	recorder, err := NewCassette(path, CassetteRecord, nil)
+	require.NoError(t, err)
+
+	edit, err := session(recorder)
+	require.NoError(t, err)
+	assert.Equal(t, "edit-1", edit.Id)
+
+	data, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "ya29.secret-token")
+	assert.NotContains(t, string(data), "session=secret")
+	assert.NotContains(t, string(data), "eyJ")
+
+	// Replay works offline and yields the same responses in the same order
+	server.Close()
+
+	player, err := NewCassette(path, CassetteReplay, nil)
+	require.NoError(t, err)
+
+	edit, err = session(player)
+	require.NoError(t, err)
+	assert.Equal(t, "edit-1", edit.Id)
+
+	// Every interaction is served once
+	_, err = session(player)
+	assert.Error(t, err)
+	assert.True(t, errors.As(err, &CassetteMissError{}))
+}
+
+func TestCassette_MatchesBody(t *testing.T) {
+	player := &Cassette{mode: CassetteReplay}
+
+	interaction := &Interaction{}
+	interaction.Request.Method = http.MethodPut
+	interaction.Request.Url = "https://www.googleapis.com/androidpublisher/v3/applications/app/edits/1/listings/en-US"
+	interaction.Request.BodyHash = scrubbedBodyHash("application/json", []byte(`{"title":"A"}`))
+	interaction.Response.Status = http.StatusOK
+
+	player.interactions = []*Interaction{interaction}
+	player.used = []bool{false}
+
+	client := player.Client()
+
+	req, _ := http.NewRequest(http.MethodPut, interaction.Request.Url, strings.NewReader(`{"title":"B"}`))
+	req.Header.Set("Content-Type", "application/json")
+	_, err := client.Do(req)
+	assert.Error(t, err)
+
+	req, _ = http.NewRequest(http.MethodPut, interaction.Request.Url, strings.NewReader(`{"title":"A"}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if assert.NoError(t, err) {
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+}
+
+func TestCassette_LeavesRequestIntact(t *testing.T) {
+	player := &Cassette{mode: CassetteReplay}
+
+	interaction := &Interaction{}
+	interaction.Request.Method = http.MethodPut
+	interaction.Request.Url = "https://www.googleapis.com/androidpublisher/v3/applications/app/edits/1/listings/en-US"
+	interaction.Request.BodyHash = scrubbedBodyHash("application/json", []byte(`{"title":"A"}`))
+	interaction.Response.Status = http.StatusOK
+
+	player.interactions = []*Interaction{interaction, interaction}
+	player.used = []bool{false, false}
+
+	// Body is taken from GetBody, so the original one stays unread
+	req, _ := http.NewRequest(http.MethodPut, interaction.Request.Url, strings.NewReader(`{"title":"A"}`))
+	req.Header.Set("Content-Type", "application/json")
+	body := req.Body
+
+	_, err := player.RoundTrip(req)
+	assert.NoError(t, err)
+	assert.Equal(t, body, req.Body)
+
+	// Non-rewindable body is consumed, but is not replaced
+	req, _ = http.NewRequest(http.MethodPut, interaction.Request.Url, ioutil.NopCloser(strings.NewReader(`{"title":"A"}`)))
+	req.Header.Set("Content-Type", "application/json")
+	body = req.Body
+
+	resp, err := player.RoundTrip(req)
+	assert.NoError(t, err)
+	assert.Equal(t, body, req.Body)
+	assert.Nil(t, req.GetBody)
+	if assert.NotNil(t, resp) {
+		assert.NotSame(t, req, resp.Request, "clone should be sent instead")
+	}
+}
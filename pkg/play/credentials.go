@@ -0,0 +1,78 @@
+package play
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+const credentialsEnvVar = "GOOGLE_APPLICATION_CREDENTIALS"
+
+var ErrNoDefaultCredentials = errors.New("could not find default credentials, set " + credentialsEnvVar + " or run 'gcloud auth application-default login'")
+
+func LoadServiceAccount(path string) (*ServiceAccount, error) {
+	var acc ServiceAccount
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	dec := json.NewDecoder(f)
+	err = dec.Decode(&acc)
+	if err != nil {
+		return nil, err
+	}
+
+	return &acc, nil
+}
+
+// FindDefaultCredentials follows Application Default Credentials discovery:
+// file pointed by GOOGLE_APPLICATION_CREDENTIALS first, then the well-known
+// file written by gcloud. It returns credentials along with their path.
+func FindDefaultCredentials() (*ServiceAccount, string, error) {
+	if path := os.Getenv(credentialsEnvVar); path != "" {
+		acc, err := LoadServiceAccount(path)
+		return acc, path, err
+	}
+
+	path := wellKnownCredentialsPath()
+	if path == "" {
+		return nil, "", ErrNoDefaultCredentials
+	}
+
+	acc, err := LoadServiceAccount(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, "", ErrNoDefaultCredentials
+		}
+		return nil, path, err
+	}
+
+	return acc, path, nil
+}
+
+func wellKnownCredentialsPath() string {
+	const file = "application_default_credentials.json"
+
+	if runtime.GOOS == "windows" {
+		if dir := os.Getenv("APPDATA"); dir != "" {
+			return filepath.Join(dir, "gcloud", file)
+		}
+		return ""
+	}
+
+	if dir := os.Getenv("CLOUDSDK_CONFIG"); dir != "" {
+		return filepath.Join(dir, file)
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(home, ".config", "gcloud", file)
+}
@@ -0,0 +1,51 @@
+package play
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindDefaultCredentials(t *testing.T) {
+	dir, err := ioutil.TempDir("", "credentials")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "user.json")
+	err = ioutil.WriteFile(path, []byte(`{
+		"type": "authorized_user",
+		"client_id": "client",
+		"client_secret": "secret",
+		"refresh_token": "refresh"
+	}`), 0600)
+	assert.Nil(t, err)
+
+	defer os.Setenv(credentialsEnvVar, os.Getenv(credentialsEnvVar))
+	defer os.Setenv("CLOUDSDK_CONFIG", os.Getenv("CLOUDSDK_CONFIG"))
+
+	// Environment variable takes precedence
+	os.Setenv(credentialsEnvVar, path)
+
+	acc, found, err := FindDefaultCredentials()
+	assert.Nil(t, err)
+	assert.Equal(t, path, found)
+	assert.Equal(t, AuthorizedUserType, acc.Type)
+	assert.Equal(t, "refresh", acc.RefreshToken)
+
+	// Then gcloud's well-known file
+	os.Setenv(credentialsEnvVar, "")
+	os.Setenv("CLOUDSDK_CONFIG", dir)
+
+	_, _, err = FindDefaultCredentials()
+	assert.Equal(t, ErrNoDefaultCredentials, err)
+
+	err = os.Rename(path, filepath.Join(dir, "application_default_credentials.json"))
+	assert.Nil(t, err)
+
+	acc, _, err = FindDefaultCredentials()
+	assert.Nil(t, err)
+	assert.Equal(t, "client", acc.ClientId)
+}
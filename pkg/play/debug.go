@@ -0,0 +1,256 @@
+package play
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"mime"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Bodies longer than this are truncated in debug output
+const debugBodyLimit = 2048
+
+const redacted = "<redacted>"
+
+var (
+	// Header values that are never logged as is
+	secretHeaders = map[string]bool{
+		"Authorization":       true,
+		"Proxy-Authorization": true,
+		"Cookie":              true,
+		"Set-Cookie":          true,
+	}
+
+	// Form and query parameters that are never logged as is
+	secretParams = map[string]bool{
+		"access_token":  true,
+		"assertion":     true,
+		"client_secret": true,
+		"refresh_token": true,
+		"subject_token": true,
+		"id_token":      true,
+		"key":           true,
+	}
+
+	secretJsonFieldsRe = regexp.MustCompile(`("(?:access_token|accessToken|refresh_token|id_token|client_secret|private_key|signedJwt|assertion|subject_token)"\s*:\s*)"(?:[^"\\]|\\.)*"`)
+	privateKeyRe       = regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`)
+	jwtRe              = regexp.MustCompile(`eyJ[A-Za-z0-9_-]+\.eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`)
+)
+
+// DebugMiddleware logs every request and response with their headers and
+// truncated bodies. Credentials are redacted and binary payloads such as
+// images are only summarized.
+func DebugMiddleware(logger *log.Logger) Middleware {
+	return func(next Doer) Doer {
+		return DoerFunc(func(req *http.Request) (*http.Response, error) {
+			var out strings.Builder
+
+			fmt.Fprintf(&out, "--> %s %s\n", req.Method, redactUrl(req.URL))
+			writeDebugHeaders(&out, req.Header)
+
+			err := writeDebugRequestBody(&out, req)
+			if err != nil {
+				return nil, err
+			}
+
+			start := time.Now()
+			resp, err := next.Do(req)
+			elapsed := time.Since(start)
+
+			if err != nil {
+				fmt.Fprintf(&out, "<-- error after %s: %s\n", elapsed, err)
+				logger.Print(out.String())
+				return nil, err
+			}
+
+			fmt.Fprintf(&out, "<-- %s (%s)\n", resp.Status, elapsed)
+			writeDebugHeaders(&out, resp.Header)
+
+			body, err := peekResponseBody(resp)
+			if err != nil {
+				logger.Print(out.String())
+				return nil, err
+			}
+			writeDebugBody(&out, resp.Header.Get("Content-Type"), resp.ContentLength, body)
+
+			logger.Print(out.String())
+
+			return resp, nil
+		})
+	}
+}
+
+func writeDebugRequestBody(w io.Writer, req *http.Request) error {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil
+	}
+
+	contentType := req.Header.Get("Content-Type")
+
+	// Binary payloads are not read at all, so that huge uploads are neither
+	// buffered in memory nor rewound
+	if mediaType, _, _ := mime.ParseMediaType(contentType); isBinaryMediaType(mediaType) {
+		writeDebugBody(w, contentType, req.ContentLength, nil)
+		return nil
+	}
+
+	body, err := peekRequestBody(req)
+	if err != nil {
+		return err
+	}
+
+	writeDebugBody(w, contentType, req.ContentLength, body)
+
+	return nil
+}
+
+// peekRequestBody returns a copy of request body leaving request intact.
+func peekRequestBody(req *http.Request) ([]byte, error) {
+	data, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	req.Body.Close()
+	req.Body = ioutil.NopCloser(bytes.NewReader(data))
+
+	return data, nil
+}
+
+func peekResponseBody(resp *http.Response) ([]byte, error) {
+	data, err := ioutil.ReadAll(io.LimitReader(resp.Body, debugBodyLimit+1))
+	if err != nil {
+		return nil, err
+	}
+
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(data), resp.Body), resp.Body}
+
+	return data, nil
+}
+
+func writeDebugHeaders(w io.Writer, header http.Header) {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		for _, value := range header[name] {
+			if secretHeaders[name] {
+				value = redactCredential(value)
+			}
+			fmt.Fprintf(w, "    %s: %s\n", name, value)
+		}
+	}
+}
+
+// redactCredential keeps authorization scheme (e.g. "Bearer") only.
+func redactCredential(value string) string {
+	if i := strings.IndexByte(value, ' '); i > 0 {
+		return value[:i] + " " + redacted
+	}
+
+	return redacted
+}
+
+func writeDebugBody(w io.Writer, contentType string, length int64, body []byte) {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+
+	if isBinaryMediaType(mediaType) {
+		if length < 0 {
+			fmt.Fprintf(w, "    <%s payload of unknown size>\n", mediaType)
+		} else {
+			fmt.Fprintf(w, "    <%s payload of %d bytes>\n", mediaType, length)
+		}
+		return
+	}
+
+	if len(body) == 0 {
+		return
+	}
+
+	truncated := len(body) > debugBodyLimit
+	if truncated {
+		body = body[:debugBodyLimit]
+	}
+
+	text := RedactSecrets(string(body))
+	if mediaType == "application/x-www-form-urlencoded" {
+		text = redactForm(text)
+	}
+
+	fmt.Fprintf(w, "    %s\n", strings.Replace(text, "\n", "\n    ", -1))
+	if truncated && length > 0 {
+		fmt.Fprintf(w, "    <truncated, %d bytes total>\n", length)
+	} else if truncated {
+		fmt.Fprintf(w, "    <truncated>\n")
+	}
+}
+
+func isBinaryMediaType(mediaType string) bool {
+	if strings.HasPrefix(mediaType, "image/") {
+		return true
+	}
+
+	switch mediaType {
+	case "application/octet-stream",
+		"application/vnd.android.package-archive",
+		"application/zip":
+		return true
+	}
+
+	return false
+}
+
+// RedactSecrets removes access tokens, JWTs and private keys from text.
+func RedactSecrets(text string) string {
+	text = privateKeyRe.ReplaceAllString(text, redacted)
+	text = secretJsonFieldsRe.ReplaceAllString(text, `$1"`+redacted+`"`)
+	text = jwtRe.ReplaceAllString(text, redacted)
+
+	return text
+}
+
+func redactForm(text string) string {
+	values, err := url.ParseQuery(text)
+	if err != nil {
+		return redacted
+	}
+
+	for name := range values {
+		if secretParams[name] {
+			values.Set(name, redacted)
+		}
+	}
+
+	return values.Encode()
+}
+
+func redactUrl(u *url.URL) string {
+	query := u.Query()
+	if len(query) == 0 {
+		return u.String()
+	}
+
+	for name := range query {
+		if secretParams[name] {
+			query.Set(name, redacted)
+		}
+	}
+
+	redactedUrl := *u
+	redactedUrl.RawQuery = query.Encode()
+
+	return redactedUrl.String()
+}
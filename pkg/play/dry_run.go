@@ -0,0 +1,169 @@
+package play
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// DryRunCall is a mutating API call that was recorded instead of being made.
+type DryRunCall struct {
+	Operation string
+	Language  string
+	ImageType EditImageType
+	Detail    string
+}
+
+// DryRun records mutating calls made through API returned by NewDryRunApi.
+type DryRun struct {
+	mu     sync.Mutex
+	calls  []DryRunCall
+	lastId int
+}
+
+// NewDryRunApi wraps api so that read-only calls are made as usual while
+// mutating ones are only recorded. Edits are still inserted and have to be
+// deleted by the caller using the original api.
+func NewDryRunApi(api *Api) (*Api, *DryRun) {
+	dryRun := &DryRun{}
+
+	return &Api{
+		Edits:    &dryRunEditsApi{EditsApi: api.Edits, dryRun: dryRun},
+		Listings: &dryRunListingsApi{EditListingsApi: api.Listings, dryRun: dryRun},
+		Images:   &dryRunImagesApi{EditImagesApi: api.Images, dryRun: dryRun},
+	}, dryRun
+}
+
+// IsDryRunApi reports whether api is returned by NewDryRunApi.
+func IsDryRunApi(api *Api) bool {
+	_, ok := api.Images.(*dryRunImagesApi)
+	return ok
+}
+
+// Calls returns recorded calls in order they were made.
+func (dryRun *DryRun) Calls() []DryRunCall {
+	dryRun.mu.Lock()
+	defer dryRun.mu.Unlock()
+
+	return append([]DryRunCall(nil), dryRun.calls...)
+}
+
+func (dryRun *DryRun) record(call DryRunCall) {
+	dryRun.mu.Lock()
+	defer dryRun.mu.Unlock()
+
+	dryRun.calls = append(dryRun.calls, call)
+}
+
+func (dryRun *DryRun) nextImageId() string {
+	dryRun.mu.Lock()
+	defer dryRun.mu.Unlock()
+
+	dryRun.lastId++
+	return fmt.Sprintf("dry-run-%d", dryRun.lastId)
+}
+
+type dryRunEditsApi struct {
+	EditsApi
+	dryRun *DryRun
+}
+
+func (api *dryRunEditsApi) Commit(ctx context.Context, token *AccessToken, packageName string, editId string) (*Edit, error) {
+	api.dryRun.record(DryRunCall{Operation: "edits.commit", Detail: editId})
+
+	return api.EditsApi.Get(ctx, token, packageName, editId)
+}
+
+func (api *dryRunEditsApi) Delete(ctx context.Context, token *AccessToken, packageName string, editId string) error {
+	api.dryRun.record(DryRunCall{Operation: "edits.delete", Detail: editId})
+
+	return nil
+}
+
+type dryRunListingsApi struct {
+	EditListingsApi
+	dryRun *DryRun
+}
+
+func (api *dryRunListingsApi) Delete(ctx context.Context, token *AccessToken, packageName string, editId string, lang string) error {
+	api.dryRun.record(DryRunCall{Operation: "listings.delete", Language: lang})
+
+	return nil
+}
+
+func (api *dryRunListingsApi) DeleteAll(ctx context.Context, token *AccessToken, packageName string, editId string) error {
+	api.dryRun.record(DryRunCall{Operation: "listings.deleteall"})
+
+	return nil
+}
+
+func (api *dryRunListingsApi) Patch(ctx context.Context, token *AccessToken, packageName string, editId string, listing *Listing, fields []ListingField) (*Listing, error) {
+	api.dryRun.record(DryRunCall{Operation: "listings.patch", Language: listing.Language, Detail: fmt.Sprintf("%v", fields)})
+
+	patched := *listing
+	return &patched, nil
+}
+
+func (api *dryRunListingsApi) Update(ctx context.Context, token *AccessToken, packageName string, editId string, listing *Listing) (*Listing, error) {
+	api.dryRun.record(DryRunCall{Operation: "listings.update", Language: listing.Language})
+
+	updated := *listing
+	return &updated, nil
+}
+
+type dryRunImagesApi struct {
+	EditImagesApi
+	dryRun *DryRun
+}
+
+func (api *dryRunImagesApi) Delete(ctx context.Context, token *AccessToken, packageName string, editId string, lang string, imageType EditImageType, imageId string) error {
+	api.dryRun.record(DryRunCall{Operation: "images.delete", Language: lang, ImageType: imageType, Detail: imageId})
+
+	return nil
+}
+
+func (api *dryRunImagesApi) DeleteAll(ctx context.Context, token *AccessToken, packageName string, editId string, lang string, imageType EditImageType) ([]Image, error) {
+	images, err := api.EditImagesApi.List(ctx, token, packageName, editId, lang, imageType)
+	if err != nil {
+		return nil, err
+	}
+
+	api.dryRun.record(DryRunCall{Operation: "images.deleteall", Language: lang, ImageType: imageType, Detail: fmt.Sprintf("%d images", len(images))})
+
+	return images, nil
+}
+
+func (api *dryRunImagesApi) Upload(ctx context.Context, token *AccessToken, packageName string, editId string, lang string, imageType EditImageType, imageReader io.ReadSeeker, opts ...UploadOption) (*Image, error) {
+	mimeType, err := detectMimeType(imageReader)
+	if err != nil {
+		return nil, err
+	}
+	if !isAcceptedMimeType(mimeType) {
+		return nil, InvalidImage{MimeType: mimeType}
+	}
+
+	hash := sha1.New()
+	size, err := io.Copy(hash, imageReader)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = imageReader.Seek(0, io.SeekStart)
+	if err != nil {
+		return nil, err
+	}
+
+	sha1sum := hex.EncodeToString(hash.Sum(nil))
+
+	detail := fmt.Sprintf("%s, %d bytes, sha1 %s", mimeType, size, sha1sum)
+	if named, ok := imageReader.(interface{ Name() string }); ok {
+		detail = named.Name() + ": " + detail
+	}
+
+	api.dryRun.record(DryRunCall{Operation: "images.upload", Language: lang, ImageType: imageType, Detail: detail})
+
+	return &Image{Id: api.dryRun.nextImageId(), Sha1: sha1sum}, nil
+}
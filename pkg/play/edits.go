@@ -5,6 +5,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strconv"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
 )
 
 const (
@@ -19,123 +23,97 @@ const (
 type Edit struct {
 	Id                string `json:"id"`
 	ExpiryTimeSeconds string `json:"expiryTimeSeconds"`
-}
 
-type editsApi struct {
-	client *http.Client
+	// Expiry is ExpiryTimeSeconds parsed, zero if unknown
+	Expiry time.Time `json:"-"`
 }
 
-func decodeEditResponse(decoder *json.Decoder) (*Edit, error) {
-	var edit Edit
+func (edit *Edit) UnmarshalJSON(data []byte) error {
+	type plainEdit Edit
 
-	err := decoder.Decode(&edit)
+	err := json.Unmarshal(data, (*plainEdit)(edit))
 	if err != nil {
-		return nil, err
+		return err
 	}
-	return &edit, nil
-}
 
-func (api *editsApi) Commit(ctx context.Context, token *AccessToken, packageName string, editId string) (*Edit, error) {
-	req, _ := http.NewRequest(http.MethodPost, fmt.Sprintf(editsApiCommit, packageName, editId), nil)
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token.AccessToken))
-
-	req = req.WithContext(ctx)
-
-	resp, err := api.client.Do(req)
-	if err != nil {
-		return nil, err
+	edit.Expiry = time.Time{}
+	if edit.ExpiryTimeSeconds != "" {
+		seconds, err := strconv.ParseInt(edit.ExpiryTimeSeconds, 10, 64)
+		if err != nil {
+			return fmt.Errorf("malformed edit expiry time '%s': %w", edit.ExpiryTimeSeconds, err)
+		}
+		edit.Expiry = time.Unix(seconds, 0)
 	}
-	defer resp.Body.Close()
-
-	dec := json.NewDecoder(resp.Body)
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, decodeApiErrorResponse(dec)
-	}
+	return nil
+}
 
-	return decodeEditResponse(dec)
+// TimeLeft returns remaining lifetime of edit, negative when it is expired.
+func (edit *Edit) TimeLeft(now time.Time) time.Duration {
+	return edit.Expiry.Sub(now)
 }
 
-func (api *editsApi) Delete(ctx context.Context, token *AccessToken, packageName string, editId string) error {
-	req, _ := http.NewRequest(http.MethodGet, fmt.Sprintf(editsApiDelete, packageName, editId), nil)
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token.AccessToken))
+type editsApi struct {
+	executor *requestExecutor
+}
 
-	req = req.WithContext(ctx)
+func (api *editsApi) do(ctx context.Context, r apiRequest) (*Edit, error) {
+	var edit Edit
 
-	resp, err := api.client.Do(req)
+	err := api.executor.execute(ctx, r, &edit)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	defer resp.Body.Close()
 
-	dec := json.NewDecoder(resp.Body)
+	return &edit, nil
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return decodeApiErrorResponse(dec)
-	}
+func (api *editsApi) Commit(ctx context.Context, token *AccessToken, packageName string, editId string) (*Edit, error) {
+	return api.do(ctx, apiRequest{
+		operation: "edits.commit",
+		attrs:     []attribute.KeyValue{PackageNameKey.String(packageName), EditIdKey.String(editId)},
+		method:    http.MethodPost,
+		url:       fmt.Sprintf(editsApiCommit, packageName, editId),
+		token:     token,
+	})
+}
 
-	return nil
+func (api *editsApi) Delete(ctx context.Context, token *AccessToken, packageName string, editId string) error {
+	return api.executor.execute(ctx, apiRequest{
+		operation: "edits.delete",
+		attrs:     []attribute.KeyValue{PackageNameKey.String(packageName), EditIdKey.String(editId)},
+		method:    http.MethodDelete,
+		url:       fmt.Sprintf(editsApiDelete, packageName, editId),
+		token:     token,
+	}, nil)
 }
 
 func (api *editsApi) Get(ctx context.Context, token *AccessToken, packageName string, editId string) (*Edit, error) {
-	req, _ := http.NewRequest(http.MethodGet, fmt.Sprintf(editsApiGet, packageName, editId), nil)
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token.AccessToken))
-
-	req = req.WithContext(ctx)
-
-	resp, err := api.client.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	dec := json.NewDecoder(resp.Body)
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, decodeApiErrorResponse(dec)
-	}
-
-	return decodeEditResponse(dec)
+	return api.do(ctx, apiRequest{
+		operation: "edits.get",
+		attrs:     []attribute.KeyValue{PackageNameKey.String(packageName), EditIdKey.String(editId)},
+		method:    http.MethodGet,
+		url:       fmt.Sprintf(editsApiGet, packageName, editId),
+		token:     token,
+	})
 }
 
 func (api *editsApi) Insert(ctx context.Context, token *AccessToken, packageName string) (*Edit, error) {
-	req, _ := http.NewRequest(http.MethodPost, fmt.Sprintf(editsApiInsert, packageName), nil)
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token.AccessToken))
-
-	req = req.WithContext(ctx)
-
-	resp, err := api.client.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	dec := json.NewDecoder(resp.Body)
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, decodeApiErrorResponse(dec)
-	}
-
-	return decodeEditResponse(dec)
+	return api.do(ctx, apiRequest{
+		operation: "edits.insert",
+		attrs:     []attribute.KeyValue{PackageNameKey.String(packageName)},
+		method:    http.MethodPost,
+		url:       fmt.Sprintf(editsApiInsert, packageName),
+		token:     token,
+	})
 }
 
 func (api *editsApi) Validate(ctx context.Context, token *AccessToken, packageName string, editId string) (*Edit, error) {
-	req, _ := http.NewRequest(http.MethodPost, fmt.Sprintf(editsApiValidate, packageName, editId), nil)
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token.AccessToken))
-
-	req = req.WithContext(ctx)
-
-	resp, err := api.client.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	dec := json.NewDecoder(resp.Body)
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, decodeApiErrorResponse(dec)
-	}
-
-	return decodeEditResponse(dec)
+	return api.do(ctx, apiRequest{
+		operation: "edits.validate",
+		attrs:     []attribute.KeyValue{PackageNameKey.String(packageName), EditIdKey.String(editId)},
+		method:    http.MethodPost,
+		url:       fmt.Sprintf(editsApiValidate, packageName, editId),
+		token:     token,
+	})
 }
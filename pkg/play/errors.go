@@ -0,0 +1,160 @@
+package play
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// Error responses are decoded from at most this many bytes, Google errors
+// with many details easily exceed a few kilobytes.
+const maxErrorResponseSize = 1024 * 1024
+
+// Only the beginning of non-JSON error bodies is kept, proxies tend to
+// respond with huge HTML pages.
+const maxErrorBodySize = 4096
+
+// ApiErrorDetail is a single entry of "errors" list of Google API error.
+type ApiErrorDetail struct {
+	Domain       string `json:"domain"`
+	Reason       string `json:"reason"`
+	Message      string `json:"message"`
+	Location     string `json:"location"`
+	LocationType string `json:"locationType"`
+}
+
+type ApiError struct {
+	ErrorDefinition struct {
+		Code    int              `json:"code"`
+		Errors  []ApiErrorDetail `json:"errors"`
+		Message string           `json:"message"`
+		Status  string           `json:"status"`
+	} `json:"error"`
+
+	// Body holds raw response when it isn't a JSON error (e.g. HTML page
+	// returned by proxy)
+	Body string `json:"-"`
+}
+
+func decodeApiErrorResponse(resp *http.Response) error {
+	data, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxErrorResponseSize))
+	if err != nil {
+		return err
+	}
+
+	var apiError ApiError
+
+	err = json.Unmarshal(data, &apiError)
+	if err != nil || (apiError.ErrorDefinition.Code == 0 && apiError.ErrorDefinition.Message == "") {
+		if len(data) > maxErrorBodySize {
+			data = data[:maxErrorBodySize]
+		}
+
+		apiError = ApiError{Body: strings.TrimSpace(string(data))}
+		apiError.ErrorDefinition.Message = http.StatusText(resp.StatusCode)
+	}
+
+	if apiError.ErrorDefinition.Code == 0 {
+		apiError.ErrorDefinition.Code = resp.StatusCode
+	}
+
+	return apiError
+}
+
+func (err ApiError) Error() string {
+	message := fmt.Sprintf("api error %d: %s", err.ErrorDefinition.Code, err.ErrorDefinition.Message)
+
+	if err.Body != "" {
+		body := err.Body
+		if len(body) > 200 {
+			body = body[:200] + "..."
+		}
+		message += fmt.Sprintf(" (non-JSON response: %s)", body)
+	}
+
+	return message
+}
+
+// Code returns HTTP status code of the error.
+func (err ApiError) Code() int {
+	return err.ErrorDefinition.Code
+}
+
+// HasReason reports whether any of error details has one of given reasons.
+func (err ApiError) HasReason(reasons ...string) bool {
+	for _, detail := range err.ErrorDefinition.Errors {
+		for _, reason := range reasons {
+			if detail.Reason == reason {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func asApiError(err error) (ApiError, bool) {
+	var apiError ApiError
+	if errors.As(err, &apiError) {
+		return apiError, true
+	}
+
+	var apiErrorPtr *ApiError
+	if errors.As(err, &apiErrorPtr) && apiErrorPtr != nil {
+		return *apiErrorPtr, true
+	}
+
+	return ApiError{}, false
+}
+
+func IsNotFound(err error) bool {
+	apiError, ok := asApiError(err)
+
+	return ok && (apiError.Code() == http.StatusNotFound || apiError.HasReason("notFound"))
+}
+
+func IsEditExpired(err error) bool {
+	apiError, ok := asApiError(err)
+	if !ok {
+		return false
+	}
+
+	if apiError.HasReason("editExpired", "editDeleted", "editAlreadyCommitted") {
+		return true
+	}
+
+	message := strings.ToLower(apiError.ErrorDefinition.Message)
+	return strings.Contains(message, "edit") &&
+		(strings.Contains(message, "expired") || strings.Contains(message, "has been deleted"))
+}
+
+func IsQuotaExceeded(err error) bool {
+	apiError, ok := asApiError(err)
+
+	return ok && (apiError.Code() == http.StatusTooManyRequests ||
+		apiError.HasReason("quotaExceeded", "rateLimitExceeded", "userRateLimitExceeded", "dailyLimitExceeded"))
+}
+
+func IsPermissionDenied(err error) bool {
+	apiError, ok := asApiError(err)
+	if !ok || IsQuotaExceeded(err) {
+		return false
+	}
+
+	return apiError.Code() == http.StatusUnauthorized || apiError.Code() == http.StatusForbidden ||
+		apiError.HasReason("forbidden", "insufficientPermissions", "permissionDenied", "authError")
+}
+
+func IsValidationError(err error) bool {
+	apiError, ok := asApiError(err)
+	if !ok || IsEditExpired(err) {
+		return false
+	}
+
+	return apiError.Code() == http.StatusBadRequest ||
+		apiError.HasReason("invalid", "invalidValue", "required", "badRequest")
+}
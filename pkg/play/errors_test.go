@@ -0,0 +1,93 @@
+package play
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func errorResponse(status int, body string) *http.Response {
+	return &http.Response{StatusCode: status, Body: ioutil.NopCloser(strings.NewReader(body))}
+}
+
+func TestDecodeApiErrorResponse(t *testing.T) {
+	err := decodeApiErrorResponse(errorResponse(404, `{
+		"error": {
+			"code": 404,
+			"message": "Package not found: com.example.project.",
+			"errors": [{"domain": "global", "reason": "notFound", "message": "Package not found", "location": "packageName", "locationType": "parameter"}]
+		}
+	}`))
+
+	apiError, ok := err.(ApiError)
+	if assert.True(t, ok) {
+		assert.Equal(t, 404, apiError.Code())
+		assert.Equal(t, "packageName", apiError.ErrorDefinition.Errors[0].Location)
+	}
+
+	wrapped := fmt.Errorf("unable to list listings: %w", err)
+	assert.True(t, IsNotFound(wrapped))
+	assert.False(t, IsPermissionDenied(wrapped))
+	assert.False(t, IsValidationError(wrapped))
+
+	// Non-JSON bodies (e.g. from proxies) are kept as is
+	err = decodeApiErrorResponse(errorResponse(502, "<html><body>Bad gateway</body></html>"))
+
+	apiError, ok = err.(ApiError)
+	if assert.True(t, ok) {
+		assert.Equal(t, 502, apiError.Code())
+		assert.Equal(t, "Bad Gateway", apiError.ErrorDefinition.Message)
+		assert.Contains(t, apiError.Error(), "<html>")
+	}
+
+	// Large JSON errors are still decoded, only raw bodies are truncated
+	var details []string
+	for i := 0; i < 50; i++ {
+		details = append(details, fmt.Sprintf(`{"domain": "androidpublisher", "reason": "editExpired", "message": "This Edit has been deleted (%d)."}`, i))
+	}
+	body := fmt.Sprintf(`{"error": {"code": 400, "message": "This Edit has been deleted.", "errors": [%s]}}`, strings.Join(details, ","))
+	assert.Greater(t, len(body), maxErrorBodySize)
+
+	err = decodeApiErrorResponse(errorResponse(400, body))
+	assert.True(t, IsEditExpired(err))
+	if apiError, ok := err.(ApiError); assert.True(t, ok) {
+		assert.Len(t, apiError.ErrorDefinition.Errors, 50)
+	}
+
+	err = decodeApiErrorResponse(errorResponse(502, strings.Repeat("x", 10*maxErrorBodySize)))
+	if apiError, ok := err.(ApiError); assert.True(t, ok) {
+		assert.Len(t, apiError.Body, maxErrorBodySize)
+	}
+}
+
+func TestApiErrorPredicates(t *testing.T) {
+	makeError := func(code int, message string, reasons ...string) error {
+		var err ApiError
+		err.ErrorDefinition.Code = code
+		err.ErrorDefinition.Message = message
+		for _, reason := range reasons {
+			err.ErrorDefinition.Errors = append(err.ErrorDefinition.Errors, ApiErrorDetail{Reason: reason})
+		}
+		return err
+	}
+
+	assert.True(t, IsEditExpired(makeError(400, "This Edit has been deleted.")))
+	assert.True(t, IsEditExpired(makeError(400, "", "editExpired")))
+	assert.False(t, IsValidationError(makeError(400, "", "editExpired")))
+
+	assert.True(t, IsQuotaExceeded(makeError(429, "")))
+	assert.True(t, IsQuotaExceeded(makeError(403, "", "dailyLimitExceeded")))
+	assert.False(t, IsPermissionDenied(makeError(403, "", "dailyLimitExceeded")))
+
+	assert.True(t, IsPermissionDenied(makeError(403, "The caller does not have permission")))
+	assert.True(t, IsPermissionDenied(&ApiError{ErrorDefinition: makeError(401, "").(ApiError).ErrorDefinition}))
+
+	assert.True(t, IsValidationError(makeError(400, "Invalid value", "invalidValue")))
+
+	assert.False(t, IsNotFound(fmt.Errorf("not an api error")))
+	assert.False(t, IsNotFound(nil))
+}
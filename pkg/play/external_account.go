@@ -0,0 +1,171 @@
+package play
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	stsUrl = "https://sts.googleapis.com/v1/token"
+
+	tokenExchangeGrantType  = "urn:ietf:params:oauth:grant-type:token-exchange"
+	accessTokenTokenType    = "urn:ietf:params:oauth:token-type:access_token"
+	credentialSourceFmtJson = "json"
+	credentialSourceFmtText = "text"
+)
+
+var ErrMissingCredentialSource = errors.New("external account credentials must define either file or url credential source")
+
+// CredentialSource describes where external account's subject token (e.g.
+// OIDC token issued by CI) should be read from.
+type CredentialSource struct {
+	File    string            `json:"file"`
+	Url     string            `json:"url"`
+	Headers map[string]string `json:"headers"`
+	Format  struct {
+		Type                  string `json:"type"`
+		SubjectTokenFieldName string `json:"subject_token_field_name"`
+	} `json:"format"`
+}
+
+type SubjectTokenError struct {
+	Source string
+	Reason string
+}
+
+func (err SubjectTokenError) Error() string {
+	return fmt.Sprintf("unable to read subject token from %s: %s", err.Source, err.Reason)
+}
+
+// WithAuthStsUrl overrides Security Token Service endpoint used to exchange
+// external account's subject tokens, it takes precedence over token_url.
+func WithAuthStsUrl(stsUrl string) AuthClientOption {
+	return func(c *AuthClient) {
+		c.stsUrl = stsUrl
+	}
+}
+
+// externalAccountToken implements workload identity federation: subject token
+// is exchanged at STS endpoint and then optionally used to impersonate
+// service account.
+func (auth *AuthClient) externalAccountToken(ctx context.Context, account *ServiceAccount, scopes []string) (*AccessToken, error) {
+	subjectToken, err := auth.subjectToken(ctx, account.CredentialSource)
+	if err != nil {
+		return nil, err
+	}
+
+	tokenUrl := auth.stsUrl
+	if tokenUrl == "" {
+		tokenUrl = account.TokenUrl
+	}
+	if tokenUrl == "" {
+		tokenUrl = stsUrl
+	}
+
+	// Federated token is only used to call IAM when impersonation is
+	// requested, otherwise it is used with requested scope directly
+	exchangeScopes := scopes
+	if account.ServiceAccountImpersonationUrl != "" {
+		exchangeScopes = []string{cloudPlatformScope}
+	}
+
+	params := url.Values{}
+	params.Set("grant_type", tokenExchangeGrantType)
+	params.Set("audience", account.Audience)
+	params.Set("scope", strings.Join(exchangeScopes, " "))
+	params.Set("requested_token_type", accessTokenTokenType)
+	params.Set("subject_token", subjectToken)
+	params.Set("subject_token_type", account.SubjectTokenType)
+
+	token, err := auth.exchangeToken(ctx, tokenUrl, &params)
+	if err != nil {
+		return nil, err
+	}
+
+	if account.ServiceAccountImpersonationUrl == "" {
+		return token, nil
+	}
+
+	return auth.generateAccessToken(ctx, account.ServiceAccountImpersonationUrl, token, nil, scopes)
+}
+
+func (auth *AuthClient) subjectToken(ctx context.Context, source *CredentialSource) (string, error) {
+	if source == nil {
+		return "", ErrMissingCredentialSource
+	}
+
+	var data []byte
+	var origin string
+	var err error
+
+	switch {
+	case source.File != "":
+		origin = source.File
+		data, err = ioutil.ReadFile(source.File)
+	case source.Url != "":
+		origin = source.Url
+		data, err = auth.fetchSubjectToken(ctx, source)
+	default:
+		return "", ErrMissingCredentialSource
+	}
+	if err != nil {
+		return "", SubjectTokenError{Source: origin, Reason: err.Error()}
+	}
+
+	switch source.Format.Type {
+	case credentialSourceFmtText, "":
+		token := strings.TrimSpace(string(data))
+		if token == "" {
+			return "", SubjectTokenError{Source: origin, Reason: "token is empty"}
+		}
+		return token, nil
+	case credentialSourceFmtJson:
+		var fields map[string]interface{}
+		err := json.Unmarshal(data, &fields)
+		if err != nil {
+			return "", SubjectTokenError{Source: origin, Reason: err.Error()}
+		}
+
+		token, ok := fields[source.Format.SubjectTokenFieldName].(string)
+		if !ok || token == "" {
+			return "", SubjectTokenError{
+				Source: origin,
+				Reason: fmt.Sprintf("field '%s' is missing", source.Format.SubjectTokenFieldName),
+			}
+		}
+		return token, nil
+	default:
+		return "", SubjectTokenError{Source: origin, Reason: fmt.Sprintf("unknown format '%s'", source.Format.Type)}
+	}
+}
+
+func (auth *AuthClient) fetchSubjectToken(ctx context.Context, source *CredentialSource) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, source.Url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	for name, value := range source.Headers {
+		req.Header.Set(name, value)
+	}
+
+	req = req.WithContext(ctx)
+
+	resp, err := auth.doer.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	return ioutil.ReadAll(resp.Body)
+}
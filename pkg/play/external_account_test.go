@@ -0,0 +1,68 @@
+package play
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthClient_AuthenticateExternalAccount(t *testing.T) {
+	expireTime := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/oidc", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "bearer ci-request-token", r.Header.Get("Authorization"))
+		w.Write([]byte(`{"value": "oidc-token"}`))
+	})
+	mux.HandleFunc("/sts", func(w http.ResponseWriter, r *http.Request) {
+		assert.Nil(t, r.ParseForm())
+		assert.Equal(t, tokenExchangeGrantType, r.PostForm.Get("grant_type"))
+		assert.Equal(t, "//iam.googleapis.com/projects/1/pools/ci/providers/github", r.PostForm.Get("audience"))
+		assert.Equal(t, "oidc-token", r.PostForm.Get("subject_token"))
+		assert.Equal(t, "urn:ietf:params:oauth:token-type:jwt", r.PostForm.Get("subject_token_type"))
+		assert.Equal(t, cloudPlatformScope, r.PostForm.Get("scope"))
+
+		w.Write([]byte(`{"access_token": "federated", "token_type": "Bearer", "expires_in": 3600}`))
+	})
+	mux.HandleFunc("/impersonate", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer federated", r.Header.Get("Authorization"))
+
+		var body generateAccessTokenRequest
+		assert.Nil(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, []string{androidPublisherScope}, body.Scope)
+
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"accessToken": "impersonated",
+			"expireTime":  expireTime.Format(time.RFC3339),
+		})
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	account := &ServiceAccount{
+		Type:                           ExternalAccountType,
+		Audience:                       "//iam.googleapis.com/projects/1/pools/ci/providers/github",
+		SubjectTokenType:               "urn:ietf:params:oauth:token-type:jwt",
+		TokenUrl:                       server.URL + "/sts",
+		ServiceAccountImpersonationUrl: server.URL + "/impersonate",
+		CredentialSource: &CredentialSource{
+			Url:     server.URL + "/oidc",
+			Headers: map[string]string{"Authorization": "bearer ci-request-token"},
+		},
+	}
+	account.CredentialSource.Format.Type = credentialSourceFmtJson
+	account.CredentialSource.Format.SubjectTokenFieldName = "value"
+
+	token, err := NewAuthClient(WithAuthHttpClient(server.Client())).Authenticate(context.Background(), account)
+	assert.Nil(t, err)
+	if assert.NotNil(t, token) {
+		assert.Equal(t, "impersonated", token.AccessToken)
+		assert.True(t, token.Expiry.Equal(expireTime))
+	}
+}
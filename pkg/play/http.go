@@ -1,8 +1,18 @@
 package play
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
 	"net/http"
+	"strings"
 	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 func defaultHttpClient() *http.Client {
@@ -10,3 +20,143 @@ func defaultHttpClient() *http.Client {
 		Timeout: 30 * time.Second,
 	}
 }
+
+// Doer is anything able to execute HTTP request, *http.Client in particular.
+type Doer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+type DoerFunc func(req *http.Request) (*http.Response, error)
+
+func (f DoerFunc) Do(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// Middleware wraps request execution, e.g. to retry, log or measure requests.
+type Middleware func(next Doer) Doer
+
+// requestExecutor performs all steps shared by API calls: building request,
+// authorizing it, passing it through middleware and decoding the response.
+type requestExecutor struct {
+	doer    Doer
+	baseUrl string
+}
+
+func newRequestExecutor(client *http.Client, middleware []Middleware) *requestExecutor {
+	return &requestExecutor{doer: chainMiddleware(client, middleware)}
+}
+
+// chainMiddleware wraps doer so that first middleware is the outermost one.
+func chainMiddleware(doer Doer, middleware []Middleware) Doer {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		doer = middleware[i](doer)
+	}
+
+	return doer
+}
+
+type apiRequest struct {
+	operation   string
+	attrs       []attribute.KeyValue
+	method      string
+	url         string
+	token       *AccessToken
+	body        io.Reader
+	contentType string
+}
+
+// jsonRequest makes request with given value encoded as JSON body.
+func jsonRequest(r apiRequest, value interface{}) (apiRequest, error) {
+	var buf bytes.Buffer
+
+	enc := json.NewEncoder(&buf)
+	err := enc.Encode(value)
+	if err != nil {
+		return apiRequest{}, err
+	}
+
+	r.body = &buf
+	r.contentType = "application/json"
+
+	return r, nil
+}
+
+// execute sends request and decodes successful response into out (unless it
+// is nil) or returns ApiError.
+func (executor *requestExecutor) execute(ctx context.Context, r apiRequest, out interface{}) (err error) {
+	ctx, span := otel.Tracer(instrumentationName).Start(ctx, "play."+r.operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(r.attrs...),
+		trace.WithAttributes(attribute.String("http.request.method", r.method)),
+	)
+	defer func() { EndSpan(span, err) }()
+
+	req, err := executor.makeRequest(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	resp, err := executor.doer.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return decodeApiErrorResponse(resp)
+	}
+
+	if out == nil {
+		return nil
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func (executor *requestExecutor) makeRequest(ctx context.Context, r apiRequest) (*http.Request, error) {
+	body := r.body
+
+	if executor.baseUrl != "" && strings.HasPrefix(r.url, googleApisUrl) {
+		r.url = executor.baseUrl + strings.TrimPrefix(r.url, googleApisUrl)
+	}
+
+	// Seekable bodies (e.g. image files) are never closed by transport and
+	// could be rewound so that middleware is able to resend them
+	seeker, isSeeker := r.body.(io.ReadSeeker)
+	if isSeeker {
+		body = ioutil.NopCloser(seeker)
+	}
+
+	req, err := http.NewRequest(r.method, r.url, body)
+	if err != nil {
+		return nil, err
+	}
+
+	if isSeeker && req.GetBody == nil {
+		size, err := seeker.Seek(0, io.SeekEnd)
+		if err != nil {
+			return nil, err
+		}
+		_, err = seeker.Seek(0, io.SeekStart)
+		if err != nil {
+			return nil, err
+		}
+
+		req.ContentLength = size
+		req.GetBody = func() (io.ReadCloser, error) {
+			_, err := seeker.Seek(0, io.SeekStart)
+			return ioutil.NopCloser(seeker), err
+		}
+	}
+
+	if r.token != nil {
+		req.Header.Set("Authorization", "Bearer "+r.token.AccessToken)
+	}
+	if r.contentType != "" {
+		req.Header.Set("Content-Type", r.contentType)
+	}
+
+	return req.WithContext(ctx), nil
+}
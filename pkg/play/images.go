@@ -2,10 +2,11 @@ package play
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+
+	"go.opentelemetry.io/otel/attribute"
 )
 
 const (
@@ -13,7 +14,7 @@ const (
 	editImagesApiDelete    = editImagesApiBaseUrl + "/%s"
 	editImagesApiDeleteAll = editImagesApiBaseUrl
 	editImagesApiList      = editImagesApiBaseUrl
-	editImagesApiUpload    = "https://www.googleapis.com/upload/androidpublisher/v3/applications/%s/edits/%s/listings/%s/%s"
+	editImagesApiUpload    = googleApisUrl + "/upload/androidpublisher/v3/applications/%s/edits/%s/listings/%s/%s"
 )
 
 type EditImageType string
@@ -30,6 +31,40 @@ const (
 	EditImageWearScreenshots      EditImageType = "wearScreenshots"
 )
 
+// EditImageTypes lists all known image types.
+var EditImageTypes = []EditImageType{
+	EditImageFeatureGraphic,
+	EditImageIcon,
+	EditImagePhoneScreenshots,
+	EditImagePromoGraphic,
+	EditImageSevenInchScreenshots,
+	EditImageTenInchScreenshots,
+	EditImageTvBanner,
+	EditImageTvScreenshots,
+	EditImageWearScreenshots,
+}
+
+// Single reports whether listing could have at most one image of the type.
+func (imageType EditImageType) Single() bool {
+	switch imageType {
+	case EditImageFeatureGraphic, EditImageIcon, EditImagePromoGraphic, EditImageTvBanner:
+		return true
+	}
+
+	return false
+}
+
+// Known reports whether type is one of EditImageTypes.
+func (imageType EditImageType) Known() bool {
+	for _, known := range EditImageTypes {
+		if known == imageType {
+			return true
+		}
+	}
+
+	return false
+}
+
 var acceptedMimeTypes = []string{
 	"image/jpeg",
 	"image/png",
@@ -57,38 +92,17 @@ type ImageList struct {
 	Images []Image `json:"images"`
 }
 
-func decodeImageResponse(decoder *json.Decoder) (*Image, error) {
-	var image struct{ Image Image `json:"image"` }
-
-	err := decoder.Decode(&image)
-	if err != nil {
-		return nil, err
-	}
-	return &image.Image, nil
-}
-
-func decodeDeletedImagesResponse(decoder *json.Decoder) (*DeletedImages, error) {
-	var deletedImages DeletedImages
-
-	err := decoder.Decode(&deletedImages)
-	if err != nil {
-		return nil, err
-	}
-	return &deletedImages, nil
-}
-
-func decodeImageListResponse(decoder *json.Decoder) (*ImageList, error) {
-	var imageList ImageList
-
-	err := decoder.Decode(&imageList)
-	if err != nil {
-		return nil, err
+func imageAttrs(packageName string, editId string, lang string, imageType EditImageType) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		PackageNameKey.String(packageName),
+		EditIdKey.String(editId),
+		LanguageKey.String(lang),
+		ImageTypeKey.String(string(imageType)),
 	}
-	return &imageList, nil
 }
 
 type editImagesApi struct {
-	client *http.Client
+	executor *requestExecutor
 }
 
 func (api *editImagesApi) Delete(
@@ -100,24 +114,13 @@ func (api *editImagesApi) Delete(
 	imageType EditImageType,
 	imageId string,
 ) error {
-	req, _ := http.NewRequest(http.MethodDelete, fmt.Sprintf(editImagesApiDelete, packageName, editId, lang, imageType, imageId), nil)
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token.AccessToken))
-
-	req = req.WithContext(ctx)
-
-	resp, err := api.client.Do(req)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-
-	dec := json.NewDecoder(resp.Body)
-
-	if resp.StatusCode != http.StatusOK {
-		return decodeApiErrorResponse(dec)
-	}
-
-	return nil
+	return api.executor.execute(ctx, apiRequest{
+		operation: "images.delete",
+		attrs:     imageAttrs(packageName, editId, lang, imageType),
+		method:    http.MethodDelete,
+		url:       fmt.Sprintf(editImagesApiDelete, packageName, editId, lang, imageType, imageId),
+		token:     token,
+	}, nil)
 }
 
 func (api *editImagesApi) DeleteAll(
@@ -128,24 +131,15 @@ func (api *editImagesApi) DeleteAll(
 	lang string,
 	imageType EditImageType,
 ) ([]Image, error) {
-	req, _ := http.NewRequest(http.MethodDelete, fmt.Sprintf(editImagesApiDeleteAll, packageName, editId, lang, imageType), nil)
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token.AccessToken))
-
-	req = req.WithContext(ctx)
-
-	resp, err := api.client.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	dec := json.NewDecoder(resp.Body)
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, decodeApiErrorResponse(dec)
-	}
-
-	list, err := decodeDeletedImagesResponse(dec)
+	var list DeletedImages
+
+	err := api.executor.execute(ctx, apiRequest{
+		operation: "images.deleteall",
+		attrs:     imageAttrs(packageName, editId, lang, imageType),
+		method:    http.MethodDelete,
+		url:       fmt.Sprintf(editImagesApiDeleteAll, packageName, editId, lang, imageType),
+		token:     token,
+	}, &list)
 	if err != nil {
 		return nil, err
 	}
@@ -161,24 +155,15 @@ func (api *editImagesApi) List(
 	lang string,
 	imageType EditImageType,
 ) ([]Image, error) {
-	req, _ := http.NewRequest(http.MethodGet, fmt.Sprintf(editImagesApiList, packageName, editId, lang, imageType), nil)
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token.AccessToken))
-
-	req = req.WithContext(ctx)
-
-	resp, err := api.client.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	dec := json.NewDecoder(resp.Body)
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, decodeApiErrorResponse(dec)
-	}
-
-	list, err := decodeImageListResponse(dec)
+	var list ImageList
+
+	err := api.executor.execute(ctx, apiRequest{
+		operation: "images.list",
+		attrs:     imageAttrs(packageName, editId, lang, imageType),
+		method:    http.MethodGet,
+		url:       fmt.Sprintf(editImagesApiList, packageName, editId, lang, imageType),
+		token:     token,
+	}, &list)
 	if err != nil {
 		return nil, err
 	}
@@ -194,33 +179,47 @@ func (api *editImagesApi) Upload(
 	lang string,
 	imageType EditImageType,
 	imageReader io.ReadSeeker,
+	opts ...UploadOption,
 ) (*Image, error) {
+	var options uploadOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
 	mimeType, err := detectMimeType(imageReader)
 	if err != nil {
 		return nil, err
 	}
 
-	if !api.ensureValidMimeType(mimeType) {
+	if !isAcceptedMimeType(mimeType) {
 		return nil, InvalidImage{MimeType: mimeType}
 	}
 
-	req, _ := http.NewRequest(http.MethodPost, fmt.Sprintf(editImagesApiUpload, packageName, editId, lang, imageType), imageReader)
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token.AccessToken))
-	req.Header.Set("Content-Type", mimeType)
-
-	resp, err := api.client.Do(req)
-	if err != nil {
-		return nil, err
+	if options.progress != nil {
+		imageReader, err = newProgressReader(imageReader, options.progress)
+		if err != nil {
+			return nil, err
+		}
 	}
-	defer resp.Body.Close()
 
-	dec := json.NewDecoder(resp.Body)
+	var uploaded struct {
+		Image Image `json:"image"`
+	}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, decodeApiErrorResponse(dec)
+	err = api.executor.execute(ctx, apiRequest{
+		operation:   "images.upload",
+		attrs:       imageAttrs(packageName, editId, lang, imageType),
+		method:      http.MethodPost,
+		url:         fmt.Sprintf(editImagesApiUpload, packageName, editId, lang, imageType),
+		token:       token,
+		body:        imageReader,
+		contentType: mimeType,
+	}, &uploaded)
+	if err != nil {
+		return nil, err
 	}
 
-	return decodeImageResponse(dec)
+	return &uploaded.Image, nil
 }
 
 func detectMimeType(r io.ReadSeeker) (string, error) {
@@ -240,7 +239,7 @@ func detectMimeType(r io.ReadSeeker) (string, error) {
 	return http.DetectContentType(buf[:n]), nil
 }
 
-func (api *editImagesApi) ensureValidMimeType(mimeType string) bool {
+func isAcceptedMimeType(mimeType string) bool {
 	for _, accepted := range acceptedMimeTypes {
 		if accepted == mimeType {
 			return true
@@ -0,0 +1,112 @@
+package play
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	iamCredentialsUrl = "https://iamcredentials.googleapis.com/v1"
+
+	cloudPlatformScope = "https://www.googleapis.com/auth/cloud-platform"
+)
+
+// WithAuthImpersonation makes client use authenticated identity only to
+// impersonate target service account. Delegates form a chain of service
+// accounts each of which has to be granted Token Creator role on the next one.
+func WithAuthImpersonation(targetServiceAccount string, delegates ...string) AuthClientOption {
+	return func(c *AuthClient) {
+		c.impersonateTarget = targetServiceAccount
+		c.delegates = delegates
+	}
+}
+
+// WithAuthIamUrl overrides IAM Service Account Credentials API base URL.
+func WithAuthIamUrl(iamUrl string) AuthClientOption {
+	return func(c *AuthClient) {
+		c.iamUrl = strings.TrimRight(iamUrl, "/")
+	}
+}
+
+func serviceAccountResource(email string) string {
+	return "projects/-/serviceAccounts/" + email
+}
+
+// impersonate exchanges source token for token of target service account.
+func (auth *AuthClient) impersonate(ctx context.Context, source *AccessToken, scopes []string) (*AccessToken, error) {
+	delegates := make([]string, 0, len(auth.delegates))
+	for _, delegate := range auth.delegates {
+		delegates = append(delegates, serviceAccountResource(delegate))
+	}
+
+	generateUrl := fmt.Sprintf("%s/%s:generateAccessToken", auth.iamUrl, serviceAccountResource(auth.impersonateTarget))
+
+	return auth.generateAccessToken(ctx, generateUrl, source, delegates, scopes)
+}
+
+type generateAccessTokenRequest struct {
+	Delegates []string `json:"delegates,omitempty"`
+	Scope     []string `json:"scope"`
+	Lifetime  string   `json:"lifetime,omitempty"`
+}
+
+type generateAccessTokenResponse struct {
+	AccessToken string    `json:"accessToken"`
+	ExpireTime  time.Time `json:"expireTime"`
+}
+
+// generateAccessToken calls IAM Credentials API to issue access token of
+// impersonated service account on behalf of given token.
+func (auth *AuthClient) generateAccessToken(
+	ctx context.Context,
+	generateUrl string,
+	token *AccessToken,
+	delegates []string,
+	scopes []string,
+) (*AccessToken, error) {
+	var buf bytes.Buffer
+
+	enc := json.NewEncoder(&buf)
+	err := enc.Encode(generateAccessTokenRequest{Delegates: delegates, Scope: scopes})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, generateUrl, &buf)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token.AccessToken))
+	req.Header.Set("Content-Type", "application/json")
+
+	req = req.WithContext(ctx)
+
+	resp, err := auth.doer.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, decodeApiErrorResponse(resp)
+	}
+
+	var generated generateAccessTokenResponse
+
+	err = json.NewDecoder(resp.Body).Decode(&generated)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AccessToken{
+		AccessToken: generated.AccessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   int(time.Until(generated.ExpireTime).Seconds()),
+		Expiry:      generated.ExpireTime,
+	}, nil
+}
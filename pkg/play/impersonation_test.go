@@ -0,0 +1,65 @@
+package play
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthClient_AuthenticateWithImpersonation(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/sts", func(w http.ResponseWriter, r *http.Request) {
+		assert.Nil(t, r.ParseForm())
+		assert.Equal(t, cloudPlatformScope, r.PostForm.Get("scope"), "source token should be able to call IAM")
+
+		w.Write([]byte(`{"access_token": "ci", "token_type": "Bearer", "expires_in": 3600}`))
+	})
+	mux.HandleFunc("/v1/projects/-/serviceAccounts/publisher@example.iam.gserviceaccount.com:generateAccessToken", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer ci", r.Header.Get("Authorization"))
+
+		var body generateAccessTokenRequest
+		assert.Nil(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, []string{"projects/-/serviceAccounts/delegate@example.iam.gserviceaccount.com"}, body.Delegates)
+		assert.Equal(t, []string{androidPublisherScope}, body.Scope)
+
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"accessToken": "publisher",
+			"expireTime":  time.Now().Add(time.Hour).Format(time.RFC3339),
+		})
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	f, err := ioutil.TempFile("", "subject-token")
+	assert.Nil(t, err)
+	defer os.Remove(f.Name())
+	f.WriteString("oidc-token\n")
+	f.Close()
+
+	account := &ServiceAccount{
+		Type:             ExternalAccountType,
+		SubjectTokenType: "urn:ietf:params:oauth:token-type:jwt",
+		TokenUrl:         server.URL + "/sts",
+		CredentialSource: &CredentialSource{File: f.Name()},
+	}
+
+	auth := NewAuthClient(
+		WithAuthHttpClient(server.Client()),
+		WithAuthIamUrl(server.URL+"/v1/"),
+		WithAuthImpersonation("publisher@example.iam.gserviceaccount.com", "delegate@example.iam.gserviceaccount.com"),
+	)
+
+	token, err := auth.Authenticate(context.Background(), account)
+	assert.Nil(t, err)
+	if assert.NotNil(t, token) {
+		assert.Equal(t, "publisher", token.AccessToken)
+	}
+}
@@ -0,0 +1,99 @@
+package play
+
+import (
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+
+	"golang.org/x/crypto/pkcs12"
+)
+
+// Password Google uses for all legacy .p12 service account keys
+const p12KeyPassword = "notasecret"
+
+var ErrMissingPrivateKey = errors.New("private key is missing")
+
+type InvalidPrivateKeyTypeError struct {
+	privateKey interface{}
+}
+
+func (err InvalidPrivateKeyTypeError) Error() string {
+	return fmt.Sprintf("invalid private key type: %T, RSA key expected", err.privateKey)
+}
+
+type MalformedPrivateKeyError struct {
+	Reason string
+}
+
+func (err MalformedPrivateKeyError) Error() string {
+	return fmt.Sprintf("malformed private key: %s", err.Reason)
+}
+
+// ParsePrivateKey parses PEM encoded RSA private key in either PKCS#1 or
+// PKCS#8 form.
+func ParsePrivateKey(data []byte) (*rsa.PrivateKey, error) {
+	if len(data) == 0 {
+		return nil, ErrMissingPrivateKey
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, MalformedPrivateKeyError{Reason: "no PEM block found"}
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	pkey, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, MalformedPrivateKeyError{Reason: fmt.Sprintf("%s block is neither PKCS#1 nor PKCS#8", block.Type)}
+	}
+
+	return rsaKey(pkey)
+}
+
+// ParseP12PrivateKey extracts RSA private key from PKCS#12 archive.
+func ParseP12PrivateKey(data []byte, password string) (*rsa.PrivateKey, error) {
+	pkey, _, err := pkcs12.Decode(data, password)
+	if err != nil {
+		return nil, MalformedPrivateKeyError{Reason: err.Error()}
+	}
+
+	return rsaKey(pkey)
+}
+
+// LoadP12ServiceAccount builds service account from legacy .p12 key file.
+// Such files do not contain account's email, so it has to be given explicitly.
+func LoadP12ServiceAccount(path string, clientEmail string) (*ServiceAccount, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := ParseP12PrivateKey(data, p12KeyPassword)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ServiceAccount{
+		Type:        ServiceAccountType,
+		ClientEmail: clientEmail,
+		PrivateKey: string(pem.EncodeToMemory(&pem.Block{
+			Type:  "RSA PRIVATE KEY",
+			Bytes: x509.MarshalPKCS1PrivateKey(key),
+		})),
+	}, nil
+}
+
+func rsaKey(pkey interface{}) (*rsa.PrivateKey, error) {
+	key, ok := pkey.(*rsa.PrivateKey)
+	if !ok {
+		return nil, InvalidPrivateKeyTypeError{privateKey: pkey}
+	}
+
+	return key, nil
+}
@@ -0,0 +1,100 @@
+package play
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePrivateKey(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	assert.Nil(t, err)
+
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(rsaKey)
+	assert.Nil(t, err)
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+
+	ecPkcs8, err := x509.MarshalPKCS8PrivateKey(ecKey)
+	assert.Nil(t, err)
+
+	encode := func(typ string, der []byte) []byte {
+		return pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der})
+	}
+
+	// PKCS#1
+	key, err := ParsePrivateKey(encode("RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey)))
+	assert.Nil(t, err)
+	assert.Equal(t, rsaKey.D, key.D)
+
+	// PKCS#8
+	key, err = ParsePrivateKey(encode("PRIVATE KEY", pkcs8))
+	assert.Nil(t, err)
+	assert.Equal(t, rsaKey.D, key.D)
+
+	// Non-RSA key
+	_, err = ParsePrivateKey(encode("PRIVATE KEY", ecPkcs8))
+	assert.IsType(t, InvalidPrivateKeyTypeError{}, err)
+	assert.Contains(t, err.Error(), "*ecdsa.PrivateKey")
+
+	// Not a PEM at all
+	_, err = ParsePrivateKey([]byte("definitely not a key"))
+	assert.IsType(t, MalformedPrivateKeyError{}, err)
+
+	// Garbage inside PEM
+	_, err = ParsePrivateKey(encode("PRIVATE KEY", []byte("garbage")))
+	assert.IsType(t, MalformedPrivateKeyError{}, err)
+
+	_, err = ParsePrivateKey(nil)
+	assert.Equal(t, ErrMissingPrivateKey, err)
+}
+
+// Fixtures are self-signed RSA and EC keys exported with password "notasecret":
+//
+//	openssl pkcs12 -export -inkey key.pem -in cert.pem -out key.p12 \
+//	    -keypbe PBE-SHA1-3DES -certpbe PBE-SHA1-3DES -macalg sha1
+func TestParseP12PrivateKey(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/service_account.p12")
+	assert.Nil(t, err)
+
+	key, err := ParseP12PrivateKey(data, p12KeyPassword)
+	assert.Nil(t, err)
+	assert.NotNil(t, key)
+
+	// Wrong password
+	_, err = ParseP12PrivateKey(data, "wrong")
+	assert.IsType(t, MalformedPrivateKeyError{}, err)
+
+	// Non-RSA key
+	data, err = ioutil.ReadFile("testdata/ec.p12")
+	assert.Nil(t, err)
+
+	_, err = ParseP12PrivateKey(data, p12KeyPassword)
+	assert.IsType(t, InvalidPrivateKeyTypeError{}, err)
+	assert.Contains(t, err.Error(), "*ecdsa.PrivateKey")
+}
+
+func TestLoadP12ServiceAccount(t *testing.T) {
+	account, err := LoadP12ServiceAccount("testdata/service_account.p12", "robot@example.iam.gserviceaccount.com")
+	assert.Nil(t, err)
+	assert.Equal(t, ServiceAccountType, account.Type)
+	assert.Equal(t, "robot@example.iam.gserviceaccount.com", account.ClientEmail)
+
+	// Private key is converted to PEM usable for signing JWTs
+	_, err = ParsePrivateKey([]byte(account.PrivateKey))
+	assert.Nil(t, err)
+
+	_, err = LoadP12ServiceAccount("testdata/ec.p12", "robot@example.iam.gserviceaccount.com")
+	assert.IsType(t, InvalidPrivateKeyTypeError{}, err)
+
+	_, err = LoadP12ServiceAccount("testdata/missing.p12", "robot@example.iam.gserviceaccount.com")
+	assert.Error(t, err)
+}
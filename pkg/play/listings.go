@@ -1,11 +1,11 @@
 package play
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
 	"net/http"
+
+	"go.opentelemetry.io/otel/attribute"
 )
 
 const (
@@ -19,7 +19,7 @@ const (
 )
 
 type editListingsApi struct {
-	client *http.Client
+	executor *requestExecutor
 }
 
 type Listing struct {
@@ -30,29 +30,60 @@ type Listing struct {
 	Video            string `json:"video"`
 }
 
-type ListingList struct {
-	Kind     string    `json:"kind"`
-	Listings []Listing `json:"listings"`
-}
+// ListingField is a name of listing's field as used by API.
+type ListingField string
 
-func decodeListingResponse(decoder *json.Decoder) (*Listing, error) {
-	var listing Listing
+const (
+	ListingTitle            ListingField = "title"
+	ListingFullDescription  ListingField = "fullDescription"
+	ListingShortDescription ListingField = "shortDescription"
+	ListingVideo            ListingField = "video"
+)
 
-	err := decoder.Decode(&listing)
-	if err != nil {
-		return nil, err
+// ListingFields are all fields of listing except for its language.
+var ListingFields = []ListingField{ListingTitle, ListingFullDescription, ListingShortDescription, ListingVideo}
+
+// Field returns value of given field.
+func (listing *Listing) Field(field ListingField) string {
+	switch field {
+	case ListingTitle:
+		return listing.Title
+	case ListingFullDescription:
+		return listing.FullDescription
+	case ListingShortDescription:
+		return listing.ShortDescription
+	case ListingVideo:
+		return listing.Video
 	}
-	return &listing, nil
+
+	return ""
 }
 
-func decodeListingListResponse(decoder *json.Decoder) (*ListingList, error) {
-	var listingList ListingList
+// SetField sets value of given field.
+func (listing *Listing) SetField(field ListingField, value string) {
+	switch field {
+	case ListingTitle:
+		listing.Title = value
+	case ListingFullDescription:
+		listing.FullDescription = value
+	case ListingShortDescription:
+		listing.ShortDescription = value
+	case ListingVideo:
+		listing.Video = value
+	}
+}
 
-	err := decoder.Decode(&listingList)
-	if err != nil {
-		return nil, err
+type ListingList struct {
+	Kind     string    `json:"kind"`
+	Listings []Listing `json:"listings"`
+}
+
+func listingAttrs(packageName string, editId string, lang string) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		PackageNameKey.String(packageName),
+		EditIdKey.String(editId),
+		LanguageKey.String(lang),
 	}
-	return &listingList, nil
 }
 
 func (api *editListingsApi) Delete(
@@ -62,24 +93,13 @@ func (api *editListingsApi) Delete(
 	editId string,
 	lang string,
 ) error {
-	req, _ := http.NewRequest(http.MethodDelete, fmt.Sprintf(editListingsApiDelete, packageName, editId, lang), nil)
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token.AccessToken))
-
-	req = req.WithContext(ctx)
-
-	resp, err := api.client.Do(req)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-
-	dec := json.NewDecoder(resp.Body)
-
-	if resp.StatusCode != http.StatusOK {
-		return decodeApiErrorResponse(dec)
-	}
-
-	return nil
+	return api.executor.execute(ctx, apiRequest{
+		operation: "listings.delete",
+		attrs:     listingAttrs(packageName, editId, lang),
+		method:    http.MethodDelete,
+		url:       fmt.Sprintf(editListingsApiDelete, packageName, editId, lang),
+		token:     token,
+	}, nil)
 }
 
 func (api *editListingsApi) DeleteAll(
@@ -88,24 +108,13 @@ func (api *editListingsApi) DeleteAll(
 	packageName string,
 	editId string,
 ) error {
-	req, _ := http.NewRequest(http.MethodDelete, fmt.Sprintf(editListingsApiDeleteAll, packageName, editId), nil)
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token.AccessToken))
-
-	req = req.WithContext(ctx)
-
-	resp, err := api.client.Do(req)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-
-	dec := json.NewDecoder(resp.Body)
-
-	if resp.StatusCode != http.StatusOK {
-		return decodeApiErrorResponse(dec)
-	}
-
-	return nil
+	return api.executor.execute(ctx, apiRequest{
+		operation: "listings.deleteall",
+		attrs:     []attribute.KeyValue{PackageNameKey.String(packageName), EditIdKey.String(editId)},
+		method:    http.MethodDelete,
+		url:       fmt.Sprintf(editListingsApiDeleteAll, packageName, editId),
+		token:     token,
+	}, nil)
 }
 
 func (api *editListingsApi) Get(
@@ -115,24 +124,20 @@ func (api *editListingsApi) Get(
 	editId string,
 	lang string,
 ) (*Listing, error) {
-	req, _ := http.NewRequest(http.MethodGet, fmt.Sprintf(editListingsApiGet, packageName, editId, lang), nil)
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token.AccessToken))
-
-	req = req.WithContext(ctx)
+	var listing Listing
 
-	resp, err := api.client.Do(req)
+	err := api.executor.execute(ctx, apiRequest{
+		operation: "listings.get",
+		attrs:     listingAttrs(packageName, editId, lang),
+		method:    http.MethodGet,
+		url:       fmt.Sprintf(editListingsApiGet, packageName, editId, lang),
+		token:     token,
+	}, &listing)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
-
-	dec := json.NewDecoder(resp.Body)
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, decodeApiErrorResponse(dec)
-	}
 
-	return decodeListingResponse(dec)
+	return &listing, nil
 }
 
 func (api *editListingsApi) List(
@@ -141,29 +146,55 @@ func (api *editListingsApi) List(
 	packageName string,
 	editId string,
 ) ([]Listing, error) {
-	req, _ := http.NewRequest(http.MethodGet, fmt.Sprintf(editListingsApiList, packageName, editId), nil)
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token.AccessToken))
-
-	req = req.WithContext(ctx)
-
-	resp, err := api.client.Do(req)
+	var list ListingList
+
+	err := api.executor.execute(ctx, apiRequest{
+		operation: "listings.list",
+		attrs:     []attribute.KeyValue{PackageNameKey.String(packageName), EditIdKey.String(editId)},
+		method:    http.MethodGet,
+		url:       fmt.Sprintf(editListingsApiList, packageName, editId),
+		token:     token,
+	}, &list)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
 
-	dec := json.NewDecoder(resp.Body)
+	return list.Listings, nil
+}
+
+// Patch updates only given fields of listing leaving others intact.
+func (api *editListingsApi) Patch(
+	ctx context.Context,
+	token *AccessToken,
+	packageName string,
+	editId string,
+	listing *Listing,
+	fields []ListingField,
+) (*Listing, error) {
+	body := map[string]string{"language": listing.Language}
+	for _, field := range fields {
+		body[string(field)] = listing.Field(field)
+	}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, decodeApiErrorResponse(dec)
+	req, err := jsonRequest(apiRequest{
+		operation: "listings.patch",
+		attrs:     listingAttrs(packageName, editId, listing.Language),
+		method:    http.MethodPatch,
+		url:       fmt.Sprintf(editListingsApiPatch, packageName, editId, listing.Language),
+		token:     token,
+	}, body)
+	if err != nil {
+		return nil, err
 	}
 
-	list, err := decodeListingListResponse(dec)
+	var patched Listing
+
+	err = api.executor.execute(ctx, req, &patched)
 	if err != nil {
 		return nil, err
 	}
 
-	return list.Listings, nil
+	return &patched, nil
 }
 
 func (api *editListingsApi) Update(
@@ -173,31 +204,23 @@ func (api *editListingsApi) Update(
 	editId string,
 	listing *Listing,
 ) (*Listing, error) {
-	var buf bytes.Buffer
-
-	enc := json.NewEncoder(&buf)
-	err := enc.Encode(listing)
+	req, err := jsonRequest(apiRequest{
+		operation: "listings.update",
+		attrs:     listingAttrs(packageName, editId, listing.Language),
+		method:    http.MethodPut,
+		url:       fmt.Sprintf(editListingsApiUpdate, packageName, editId, listing.Language),
+		token:     token,
+	}, listing)
 	if err != nil {
 		return nil, err
 	}
 
-	req, _ := http.NewRequest(http.MethodPut, fmt.Sprintf(editListingsApiUpdate, packageName, editId, listing.Language), &buf)
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token.AccessToken))
-	req.Header.Set("Content-Type", "application/json")
+	var updated Listing
 
-	req = req.WithContext(ctx)
-
-	resp, err := api.client.Do(req)
+	err = api.executor.execute(ctx, req, &updated)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
-
-	dec := json.NewDecoder(resp.Body)
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, decodeApiErrorResponse(dec)
-	}
 
-	return decodeListingResponse(dec)
+	return &updated, nil
 }
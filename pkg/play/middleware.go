@@ -0,0 +1,170 @@
+package play
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"time"
+)
+
+const requestIdHeader = "X-Request-Id"
+
+// UserAgentMiddleware sets User-Agent header of every request.
+func UserAgentMiddleware(userAgent string) Middleware {
+	return func(next Doer) Doer {
+		return DoerFunc(func(req *http.Request) (*http.Response, error) {
+			req.Header.Set("User-Agent", userAgent)
+			return next.Do(req)
+		})
+	}
+}
+
+// RequestIdMiddleware assigns random X-Request-Id to requests without one.
+func RequestIdMiddleware() Middleware {
+	return func(next Doer) Doer {
+		return DoerFunc(func(req *http.Request) (*http.Response, error) {
+			if req.Header.Get(requestIdHeader) == "" {
+				req.Header.Set(requestIdHeader, newRequestId())
+			}
+			return next.Do(req)
+		})
+	}
+}
+
+func newRequestId() string {
+	buf := make([]byte, 16)
+	rand.Read(buf)
+
+	return hex.EncodeToString(buf)
+}
+
+type postRetryKey struct{}
+
+// AllowPostRetry lets RetryMiddleware resend POST requests made with returned
+// context. Only use it when a duplicate request is harmless, as a lost response
+// could otherwise e.g. insert a second edit or upload an image twice.
+func AllowPostRetry(ctx context.Context) context.Context {
+	return context.WithValue(ctx, postRetryKey{}, true)
+}
+
+// RetryMiddleware resends idempotent requests failed with network error, 429
+// or 5xx status using exponential backoff. POST requests are only retried when
+// allowed by AllowPostRetry, requests whose body can't be rewound are never
+// retried.
+func RetryMiddleware(maxAttempts int, backoff time.Duration) Middleware {
+	return func(next Doer) Doer {
+		return DoerFunc(func(req *http.Request) (*http.Response, error) {
+			delay := backoff
+
+			for attempt := 1; ; attempt++ {
+				resp, err := next.Do(req)
+
+				if attempt >= maxAttempts || !isRetryable(resp, err) || !canResend(req) {
+					return resp, err
+				}
+
+				if resp != nil {
+					io.Copy(ioutil.Discard, resp.Body)
+					resp.Body.Close()
+				}
+
+				select {
+				case <-req.Context().Done():
+					return nil, req.Context().Err()
+				case <-time.After(delay):
+				}
+				delay *= 2
+
+				if req.GetBody != nil {
+					body, err := req.GetBody()
+					if err != nil {
+						return nil, err
+					}
+
+					req = req.Clone(req.Context())
+					req.Body = body
+				}
+			}
+		})
+	}
+}
+
+func canResend(req *http.Request) bool {
+	if req.Body != nil && req.GetBody == nil {
+		return false
+	}
+
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+
+	allowed, _ := req.Context().Value(postRetryKey{}).(bool)
+	return allowed
+}
+
+func isRetryable(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests,
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	}
+
+	return false
+}
+
+// RequestMetrics describes a single executed request.
+type RequestMetrics struct {
+	Method     string
+	Url        string
+	StatusCode int
+	Duration   time.Duration
+	Err        error
+}
+
+// MetricsMiddleware reports every executed request to observe function.
+func MetricsMiddleware(observe func(metrics RequestMetrics)) Middleware {
+	return func(next Doer) Doer {
+		return DoerFunc(func(req *http.Request) (*http.Response, error) {
+			start := time.Now()
+			resp, err := next.Do(req)
+
+			metrics := RequestMetrics{
+				Method:   req.Method,
+				Url:      req.URL.String(),
+				Duration: time.Since(start),
+				Err:      err,
+			}
+			if resp != nil {
+				metrics.StatusCode = resp.StatusCode
+			}
+
+			observe(metrics)
+
+			return resp, err
+		})
+	}
+}
+
+// LoggingMiddleware logs method, URL, status and latency of every request.
+func LoggingMiddleware(logger *log.Logger) Middleware {
+	return MetricsMiddleware(func(m RequestMetrics) {
+		if m.Err != nil {
+			logger.Printf("%s %s failed in %s: %s", m.Method, m.Url, m.Duration, m.Err)
+			return
+		}
+
+		logger.Printf("%s %s %d in %s", m.Method, m.Url, m.StatusCode, m.Duration)
+	})
+}
@@ -0,0 +1,87 @@
+package play
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestExecutor_Middleware(t *testing.T) {
+	var bodies []string
+	attempts := 0
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+
+		assert.Equal(t, "Bearer access_token", r.Header.Get("Authorization"))
+		assert.Equal(t, "test-agent", r.Header.Get("User-Agent"))
+		assert.NotEmpty(t, r.Header.Get(requestIdHeader))
+
+		body, _ := ioutil.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+
+		if attempts < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(`{"error": {"code": 503, "message": "try later"}}`))
+			return
+		}
+
+		w.Write([]byte(`{"image": {"id": "uploaded"}}`))
+	}))
+	defer server.Close()
+
+	var observed []RequestMetrics
+
+	executor := newRequestExecutor(server.Client(), []Middleware{
+		MetricsMiddleware(func(m RequestMetrics) { observed = append(observed, m) }),
+		UserAgentMiddleware("test-agent"),
+		RequestIdMiddleware(),
+		RetryMiddleware(3, time.Millisecond),
+	})
+
+	var out struct {
+		Image Image `json:"image"`
+	}
+
+	err := executor.execute(AllowPostRetry(context.Background()), apiRequest{
+		method: http.MethodPost,
+		url:    server.URL,
+		token:  &AccessToken{AccessToken: "access_token"},
+		body:   bytes.NewReader([]byte("image")),
+	}, &out)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "uploaded", out.Image.Id)
+
+	// Seekable body should be resent as a whole on each attempt
+	assert.Equal(t, []string{"image", "image", "image"}, bodies)
+
+	// Outer middleware observes single logical request
+	if assert.Len(t, observed, 1) {
+		assert.Equal(t, http.StatusOK, observed[0].StatusCode)
+	}
+
+	// POST is not retried unless allowed
+	attempts, bodies = 0, nil
+	err = executor.execute(context.Background(), apiRequest{
+		method: http.MethodPost,
+		url:    server.URL,
+		token:  &AccessToken{AccessToken: "access_token"},
+		body:   bytes.NewReader([]byte("image")),
+	}, &out)
+	assert.IsType(t, ApiError{}, err)
+	assert.Equal(t, 1, attempts)
+
+	// Error is decoded once retries are exhausted
+	attempts = -10
+	err = executor.execute(context.Background(), apiRequest{method: http.MethodGet, url: server.URL, token: &AccessToken{AccessToken: "access_token"}}, nil)
+	if assert.IsType(t, ApiError{}, err) {
+		assert.Equal(t, 503, err.(ApiError).ErrorDefinition.Code)
+	}
+}
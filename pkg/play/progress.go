@@ -0,0 +1,66 @@
+package play
+
+import (
+	"io"
+)
+
+type UploadProgress struct {
+	Sent  int64
+	Total int64
+}
+
+type UploadOption func(o *uploadOptions)
+
+type uploadOptions struct {
+	progress func(progress UploadProgress)
+}
+
+// WithUploadProgress makes Upload report number of bytes sent so far. When
+// request is retried progress starts from zero again.
+func WithUploadProgress(progress func(progress UploadProgress)) UploadOption {
+	return func(o *uploadOptions) {
+		o.progress = progress
+	}
+}
+
+// progressReader reports bytes read from underlying reader.
+type progressReader struct {
+	r        io.ReadSeeker
+	total    int64
+	sent     int64
+	progress func(progress UploadProgress)
+}
+
+func newProgressReader(r io.ReadSeeker, progress func(progress UploadProgress)) (*progressReader, error) {
+	total, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = r.Seek(0, io.SeekStart)
+	if err != nil {
+		return nil, err
+	}
+
+	return &progressReader{r: r, total: total, progress: progress}, nil
+}
+
+func (pr *progressReader) Read(p []byte) (int, error) {
+	n, err := pr.r.Read(p)
+
+	if n > 0 {
+		pr.sent += int64(n)
+		pr.progress(UploadProgress{Sent: pr.sent, Total: pr.total})
+	}
+
+	return n, err
+}
+
+func (pr *progressReader) Seek(offset int64, whence int) (int64, error) {
+	pos, err := pr.r.Seek(offset, whence)
+	if err == nil {
+		pr.sent = pos
+	}
+
+	return pos, err
+}
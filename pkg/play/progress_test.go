@@ -0,0 +1,34 @@
+package play
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProgressReader(t *testing.T) {
+	var reports []UploadProgress
+
+	pr, err := newProgressReader(bytes.NewReader([]byte("0123456789")), func(progress UploadProgress) {
+		reports = append(reports, progress)
+	})
+	assert.Nil(t, err)
+
+	buf := make([]byte, 4)
+	pr.Read(buf)
+	pr.Read(buf)
+
+	assert.Equal(t, []UploadProgress{{Sent: 4, Total: 10}, {Sent: 8, Total: 10}}, reports)
+
+	// Rewinding (e.g. on retry) starts progress over
+	reports = nil
+	_, err = pr.Seek(0, 0)
+	assert.Nil(t, err)
+
+	data, err := ioutil.ReadAll(pr)
+	assert.Nil(t, err)
+	assert.Equal(t, "0123456789", string(data))
+	assert.Equal(t, UploadProgress{Sent: 10, Total: 10}, reports[len(reports)-1])
+}
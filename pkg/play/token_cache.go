@@ -0,0 +1,158 @@
+package play
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Tokens that expire sooner than this are considered stale and are not
+// returned from the cache.
+const tokenCacheExpiryLeeway = 5 * time.Minute
+
+type TokenCache interface {
+	Get(key string) (*AccessToken, error)
+	Put(key string, token *AccessToken) error
+	Purge() error
+}
+
+// TokenCacheKey builds cache key from account identity (including token
+// endpoint) and requested scopes, so tokens issued for different scopes are
+// never mixed up.
+func TokenCacheKey(identity string, scopes ...string) string {
+	sorted := append([]string(nil), scopes...)
+	sort.Strings(sorted)
+
+	return identity + " " + strings.Join(sorted, " ")
+}
+
+// FileTokenCache stores access tokens in a single JSON file that is readable
+// by the owner only.
+type FileTokenCache struct {
+	path string
+	mu   sync.Mutex
+}
+
+func NewFileTokenCache(path string) *FileTokenCache {
+	return &FileTokenCache{path: path}
+}
+
+// DefaultTokenCachePath returns token cache location inside user's cache dir.
+func DefaultTokenCachePath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "google-play-edit", "tokens.json"), nil
+}
+
+func (cache *FileTokenCache) Get(key string) (*AccessToken, error) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	tokens, err := cache.load()
+	if err != nil {
+		return nil, err
+	}
+
+	token, ok := tokens[key]
+	if !ok || !token.ValidFor(tokenCacheExpiryLeeway) {
+		return nil, nil
+	}
+
+	if !token.Expiry.IsZero() {
+		token.ExpiresIn = int(time.Until(token.Expiry).Seconds())
+	}
+
+	return token, nil
+}
+
+func (cache *FileTokenCache) Put(key string, token *AccessToken) error {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	tokens, err := cache.load()
+	if err != nil {
+		return err
+	}
+
+	for k, t := range tokens {
+		if !t.ValidFor(0) {
+			delete(tokens, k)
+		}
+	}
+	tokens[key] = token
+
+	return cache.store(tokens)
+}
+
+func (cache *FileTokenCache) Purge() error {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	err := os.Remove(cache.path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
+
+func (cache *FileTokenCache) load() (map[string]*AccessToken, error) {
+	tokens := make(map[string]*AccessToken)
+
+	data, err := ioutil.ReadFile(cache.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return tokens, nil
+		}
+		return nil, err
+	}
+
+	// Corrupted cache is not fatal, it will be overwritten on next Put
+	if err := json.Unmarshal(data, &tokens); err != nil {
+		return make(map[string]*AccessToken), nil
+	}
+
+	return tokens, nil
+}
+
+func (cache *FileTokenCache) store(tokens map[string]*AccessToken) error {
+	dir := filepath.Dir(cache.path)
+
+	err := os.MkdirAll(dir, 0700)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(tokens)
+	if err != nil {
+		return err
+	}
+
+	f, err := ioutil.TempFile(dir, ".tokens-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	// TempFile already creates file with 0600, but be explicit about it
+	err = f.Chmod(0600)
+	if err == nil {
+		_, err = f.Write(data)
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), cache.path)
+}
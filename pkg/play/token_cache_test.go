@@ -0,0 +1,126 @@
+package play
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileTokenCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "token-cache")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "nested", "tokens.json")
+	cache := NewFileTokenCache(path)
+
+	key := TokenCacheKey("svc@example.com", androidPublisherScope)
+
+	// Missing cache file is not an error
+	token, err := cache.Get(key)
+	assert.Nil(t, err)
+	assert.Nil(t, token)
+
+	err = cache.Put(key, &AccessToken{AccessToken: "fresh", Expiry: time.Now().Add(time.Hour)})
+	assert.Nil(t, err)
+
+	info, err := os.Stat(path)
+	assert.Nil(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm(), "cache should be readable by owner only")
+
+	// Token should survive between cache instances
+	token, err = NewFileTokenCache(path).Get(key)
+	assert.Nil(t, err)
+	if assert.NotNil(t, token) {
+		assert.Equal(t, "fresh", token.AccessToken)
+	}
+
+	// Token for other scope should not be returned
+	token, err = cache.Get(TokenCacheKey("svc@example.com", "other"))
+	assert.Nil(t, err)
+	assert.Nil(t, token)
+
+	// Token close to expiry should not be returned
+	err = cache.Put(key, &AccessToken{AccessToken: "stale", Expiry: time.Now().Add(time.Minute)})
+	assert.Nil(t, err)
+
+	token, err = cache.Get(key)
+	assert.Nil(t, err)
+	assert.Nil(t, token)
+
+	assert.Nil(t, cache.Purge())
+	assert.Nil(t, cache.Purge(), "purging missing cache should not fail")
+
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestAuthClient_AuthenticateWithTokenCache(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	assert.Nil(t, err)
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte(`{"access_token": "token", "token_type": "Bearer", "expires_in": 3600}`))
+	}))
+	defer server.Close()
+
+	account := &ServiceAccount{
+		Type:        ServiceAccountType,
+		ClientEmail: "svc@example.iam.gserviceaccount.com",
+		PrivateKey:  string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})),
+		TokenUri:    server.URL + "/token",
+	}
+
+	dir, err := ioutil.TempDir("", "token-cache")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	// Directory in place of cache file could be neither read nor written
+	path := filepath.Join(dir, "tokens.json")
+	assert.Nil(t, os.Mkdir(path, 0700))
+
+	var warnings []error
+	authenticate := func(opts ...AuthClientOption) {
+		opts = append(opts,
+			WithAuthHttpClient(server.Client()),
+			WithAuthTokenCache(NewFileTokenCache(path), func(err error) { warnings = append(warnings, err) }),
+		)
+
+		token, err := NewAuthClient(opts...).Authenticate(context.Background(), account)
+		assert.Nil(t, err)
+		if assert.NotNil(t, token) {
+			assert.Equal(t, "token", token.AccessToken)
+		}
+	}
+
+	// Unusable cache doesn't fail authentication, it is only reported
+	authenticate()
+	assert.Equal(t, 1, requests)
+	assert.Len(t, warnings, 2)
+
+	assert.Nil(t, os.Remove(path))
+	warnings = nil
+
+	authenticate()
+	authenticate()
+	assert.Equal(t, 2, requests, "cached token should be reused")
+
+	// Token issued by other endpoint should not be reused
+	authenticate(WithAuthTokenUrl(server.URL + "/other"))
+	assert.Equal(t, 3, requests)
+
+	assert.Empty(t, warnings)
+}
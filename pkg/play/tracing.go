@@ -0,0 +1,36 @@
+package play
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Spans are recorded with globally registered tracer provider, which is no-op
+// unless application configures one.
+const instrumentationName = "github.com/yurykabanov/google-play-edit/pkg/play"
+
+// Attributes shared by spans of API calls and tasks
+const (
+	PackageNameKey = attribute.Key("play.package_name")
+	EditIdKey      = attribute.Key("play.edit_id")
+	LanguageKey    = attribute.Key("play.language")
+	ImageTypeKey   = attribute.Key("play.image_type")
+)
+
+func StartSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// EndSpan records error (if any) and ends span.
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
+}
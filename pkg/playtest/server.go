@@ -0,0 +1,625 @@
+// Package playtest provides in-memory fake of Google Play Developer API
+// (edits, listings and images) for offline tests.
+package playtest
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yurykabanov/google-play-edit/pkg/play"
+)
+
+const (
+	apiPrefix    = "/androidpublisher/v3/applications/"
+	uploadPrefix = "/upload/androidpublisher/v3/applications/"
+	tokenPath    = "/token"
+
+	// Real edits live for about a week
+	DefaultEditLifetime = 7 * 24 * time.Hour
+)
+
+// Server is a fake Google Play Developer API. Every edit starts as a copy of
+// application's committed state, commit replaces that state and invalidates
+// all other edits of the application.
+type Server struct {
+	*httptest.Server
+
+	// EditLifetime is the lifetime of newly inserted edits
+	EditLifetime time.Duration
+
+	// Now is server's clock, override it to test edit expiry
+	Now func() time.Time
+
+	mu       sync.Mutex
+	apps     map[string]*state
+	edits    map[string]*edit
+	faults   []*Fault
+	requests []string
+	lastId   int
+}
+
+type imageKey struct {
+	lang      string
+	imageType play.EditImageType
+}
+
+type state struct {
+	listings map[string]play.Listing
+	images   map[imageKey][]storedImage
+}
+
+type storedImage struct {
+	play.Image
+	Data []byte
+}
+
+type edit struct {
+	id          string
+	packageName string
+	expiry      time.Time
+	state       *state
+}
+
+// Fault makes server respond with an error to matching requests.
+type Fault struct {
+	// Method and Path (substring of URL path) select requests, empty values
+	// match everything
+	Method string
+	Path   string
+
+	Status  int
+	Reason  string
+	Message string
+
+	// Times is the number of requests to fail, zero means forever
+	Times int
+}
+
+func newState() *state {
+	return &state{
+		listings: make(map[string]play.Listing),
+		images:   make(map[imageKey][]storedImage),
+	}
+}
+
+func (s *state) clone() *state {
+	c := newState()
+
+	for lang, listing := range s.listings {
+		c.listings[lang] = listing
+	}
+	for key, images := range s.images {
+		c.images[key] = append([]storedImage(nil), images...)
+	}
+
+	return c
+}
+
+// NewServer starts fake server, it should be closed by the caller.
+func NewServer() *Server {
+	s := &Server{
+		EditLifetime: DefaultEditLifetime,
+		Now:          time.Now,
+		apps:         make(map[string]*state),
+		edits:        make(map[string]*edit),
+	}
+
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+
+	return s
+}
+
+// Api returns client configured to talk to the server.
+func (s *Server) Api(opts ...play.ApiClientOption) *play.Api {
+	return play.NewApi(append([]play.ApiClientOption{
+		play.WithApiHttpClient(s.Client()),
+		play.WithApiBaseUrl(s.URL),
+	}, opts...)...)
+}
+
+// TokenUrl returns URL of fake OAuth 2.0 token endpoint accepting any grant.
+func (s *Server) TokenUrl() string {
+	return s.URL + tokenPath
+}
+
+// InjectFault registers fault, the most recently added faults win.
+func (s *Server) InjectFault(fault Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.faults = append([]*Fault{&fault}, s.faults...)
+}
+
+// Requests returns "METHOD /path" of every request received so far.
+func (s *Server) Requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]string(nil), s.requests...)
+}
+
+// SetListing sets committed listing of application.
+func (s *Server) SetListing(packageName string, listing play.Listing) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.app(packageName).listings[listing.Language] = listing
+}
+
+// AddImage appends committed image of application and returns its metadata.
+func (s *Server) AddImage(packageName string, lang string, imageType play.EditImageType, data []byte) play.Image {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	app := s.app(packageName)
+	key := imageKey{lang, imageType}
+	image := s.newImage(data)
+
+	app.images[key] = append(app.images[key], image)
+
+	return image.Image
+}
+
+// Listings returns committed listings of application sorted by language.
+func (s *Server) Listings(packageName string) []play.Listing {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return sortedListings(s.app(packageName))
+}
+
+// Images returns committed images of application.
+func (s *Server) Images(packageName string, lang string, imageType play.EditImageType) []play.Image {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return imagesOf(s.app(packageName), imageType, lang)
+}
+
+// EditListings returns listings of an open edit.
+func (s *Server) EditListings(editId string) []play.Listing {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.edits[editId]
+	if !ok {
+		return nil
+	}
+
+	return sortedListings(e.state)
+}
+
+// EditImages returns images of an open edit.
+func (s *Server) EditImages(editId string, lang string, imageType play.EditImageType) []play.Image {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.edits[editId]
+	if !ok {
+		return nil
+	}
+
+	return imagesOf(e.state, imageType, lang)
+}
+
+// EditIds returns IDs of edits that are neither committed nor deleted.
+func (s *Server) EditIds() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var ids []string
+	for id := range s.edits {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	return ids
+}
+
+// ExpireEdit makes edit expired immediately.
+func (s *Server) ExpireEdit(editId string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e, ok := s.edits[editId]; ok {
+		e.expiry = s.Now().Add(-time.Second)
+	}
+}
+
+func (s *Server) app(packageName string) *state {
+	app, ok := s.apps[packageName]
+	if !ok {
+		app = newState()
+		s.apps[packageName] = app
+	}
+
+	return app
+}
+
+func (s *Server) nextId() string {
+	s.lastId++
+	return strconv.Itoa(s.lastId)
+}
+
+func (s *Server) newImage(data []byte) storedImage {
+	sha1sum := sha1.Sum(data)
+	id := s.nextId()
+
+	return storedImage{
+		Image: play.Image{
+			Id:   id,
+			Url:  s.URL + "/images/" + id,
+			Sha1: hex.EncodeToString(sha1sum[:]),
+		},
+		Data: data,
+	}
+}
+
+func sortedListings(st *state) []play.Listing {
+	listings := make([]play.Listing, 0, len(st.listings))
+	for _, listing := range st.listings {
+		listings = append(listings, listing)
+	}
+
+	sort.Slice(listings, func(i, j int) bool { return listings[i].Language < listings[j].Language })
+
+	return listings
+}
+
+func imagesOf(st *state, imageType play.EditImageType, lang string) []play.Image {
+	var images []play.Image
+	for _, image := range st.images[imageKey{lang, imageType}] {
+		images = append(images, image.Image)
+	}
+
+	return images
+}
+
+type apiError struct {
+	status  int
+	reason  string
+	message string
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests = append(s.requests, r.Method+" "+r.URL.Path)
+
+	if fault := s.matchFault(r); fault != nil {
+		writeError(w, apiError{fault.Status, fault.Reason, fault.Message})
+		return
+	}
+
+	if r.URL.Path == tokenPath {
+		writeJson(w, map[string]interface{}{
+			"access_token": "playtest-token",
+			"token_type":   "Bearer",
+			"expires_in":   3600,
+		})
+		return
+	}
+
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "Bearer ") {
+		writeError(w, apiError{http.StatusUnauthorized, "authError", "Request is missing required authentication credential."})
+		return
+	}
+
+	var resp interface{}
+	var err *apiError
+
+	switch {
+	case strings.HasPrefix(r.URL.Path, uploadPrefix):
+		resp, err = s.handleUpload(r, splitPath(r.URL.Path, uploadPrefix))
+	case strings.HasPrefix(r.URL.Path, apiPrefix):
+		resp, err = s.handleApi(r, splitPath(r.URL.Path, apiPrefix))
+	default:
+		err = &apiError{http.StatusNotFound, "notFound", "Not Found"}
+	}
+
+	if err != nil {
+		writeError(w, *err)
+		return
+	}
+
+	if resp == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	writeJson(w, resp)
+}
+
+func (s *Server) matchFault(r *http.Request) *Fault {
+	for i, fault := range s.faults {
+		if fault.Method != "" && fault.Method != r.Method {
+			continue
+		}
+		if fault.Path != "" && !strings.Contains(r.URL.Path, fault.Path) {
+			continue
+		}
+
+		if fault.Times > 0 {
+			fault.Times--
+			if fault.Times == 0 {
+				s.faults = append(s.faults[:i], s.faults[i+1:]...)
+			}
+		}
+
+		return fault
+	}
+
+	return nil
+}
+
+func splitPath(path string, prefix string) []string {
+	var parts []string
+	for _, part := range strings.Split(strings.TrimPrefix(path, prefix), "/") {
+		if unescaped, err := url.PathUnescape(part); err == nil {
+			part = unescaped
+		}
+		parts = append(parts, part)
+	}
+
+	return parts
+}
+
+// handleApi serves {packageName}/edits[/{editId}[/listings[/{lang}[/{imageType}[/{imageId}]]]]]
+func (s *Server) handleApi(r *http.Request, parts []string) (interface{}, *apiError) {
+	if len(parts) < 2 || parts[1] != "edits" {
+		return nil, &apiError{http.StatusNotFound, "notFound", "Not Found"}
+	}
+	packageName := parts[0]
+
+	if len(parts) == 2 {
+		if r.Method != http.MethodPost {
+			return nil, methodNotAllowed()
+		}
+		return s.insertEdit(packageName), nil
+	}
+
+	editId, action := parts[2], ""
+	if i := strings.IndexByte(editId, ':'); i >= 0 {
+		editId, action = editId[:i], editId[i+1:]
+	}
+
+	e, err := s.openEdit(packageName, editId)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(parts) == 3 {
+		return s.handleEdit(r, e, action)
+	}
+
+	if parts[3] != "listings" {
+		return nil, &apiError{http.StatusNotFound, "notFound", "Not Found"}
+	}
+
+	switch len(parts) {
+	case 4:
+		return s.handleListings(r, e)
+	case 5:
+		return s.handleListing(r, e, parts[4])
+	case 6:
+		return s.handleImages(r, e, parts[4], play.EditImageType(parts[5]))
+	case 7:
+		if r.Method != http.MethodDelete {
+			return nil, methodNotAllowed()
+		}
+		return s.deleteImage(e, parts[4], play.EditImageType(parts[5]), parts[6])
+	}
+
+	return nil, &apiError{http.StatusNotFound, "notFound", "Not Found"}
+}
+
+func (s *Server) insertEdit(packageName string) *play.Edit {
+	e := &edit{
+		id:          s.nextId(),
+		packageName: packageName,
+		expiry:      s.Now().Add(s.EditLifetime),
+		state:       s.app(packageName).clone(),
+	}
+	s.edits[e.id] = e
+
+	return e.toEdit()
+}
+
+func (e *edit) toEdit() *play.Edit {
+	return &play.Edit{Id: e.id, ExpiryTimeSeconds: strconv.FormatInt(e.expiry.Unix(), 10)}
+}
+
+func (s *Server) openEdit(packageName string, editId string) (*edit, *apiError) {
+	e, ok := s.edits[editId]
+	if !ok || e.packageName != packageName {
+		return nil, &apiError{http.StatusNotFound, "notFound", fmt.Sprintf("Edit %s not found.", editId)}
+	}
+
+	if !s.Now().Before(e.expiry) {
+		return nil, &apiError{http.StatusBadRequest, "editExpired", "This Edit has expired."}
+	}
+
+	return e, nil
+}
+
+func (s *Server) handleEdit(r *http.Request, e *edit, action string) (interface{}, *apiError) {
+	switch {
+	case action == "" && r.Method == http.MethodGet:
+		return e.toEdit(), nil
+	case action == "" && r.Method == http.MethodDelete:
+		delete(s.edits, e.id)
+		return nil, nil
+	case action == "validate" && r.Method == http.MethodPost:
+		return e.toEdit(), nil
+	case action == "commit" && r.Method == http.MethodPost:
+		s.apps[e.packageName] = e.state
+
+		// Committing an edit invalidates all other edits of application
+		for id, other := range s.edits {
+			if other.packageName == e.packageName {
+				delete(s.edits, id)
+			}
+		}
+
+		return e.toEdit(), nil
+	}
+
+	return nil, methodNotAllowed()
+}
+
+func (s *Server) handleListings(r *http.Request, e *edit) (interface{}, *apiError) {
+	switch r.Method {
+	case http.MethodGet:
+		return play.ListingList{Kind: "androidpublisher#listingsListResponse", Listings: sortedListings(e.state)}, nil
+	case http.MethodDelete:
+		e.state.listings = make(map[string]play.Listing)
+		e.state.images = make(map[imageKey][]storedImage)
+		return nil, nil
+	}
+
+	return nil, methodNotAllowed()
+}
+
+func (s *Server) handleListing(r *http.Request, e *edit, lang string) (interface{}, *apiError) {
+	listing, exists := e.state.listings[lang]
+
+	switch r.Method {
+	case http.MethodGet:
+		if !exists {
+			return nil, &apiError{http.StatusNotFound, "notFound", fmt.Sprintf("Listing for language %s not found.", lang)}
+		}
+		return listing, nil
+
+	case http.MethodDelete:
+		delete(e.state.listings, lang)
+		for key := range e.state.images {
+			if key.lang == lang {
+				delete(e.state.images, key)
+			}
+		}
+		return nil, nil
+
+	case http.MethodPut, http.MethodPatch:
+		if r.Method == http.MethodPatch && !exists {
+			return nil, &apiError{http.StatusNotFound, "notFound", fmt.Sprintf("Listing for language %s not found.", lang)}
+		}
+
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			return nil, &apiError{http.StatusBadRequest, "badRequest", err.Error()}
+		}
+
+		var fields map[string]interface{}
+		if err := json.Unmarshal(body, &fields); err != nil {
+			return nil, &apiError{http.StatusBadRequest, "parseError", "Parse Error"}
+		}
+
+		// PUT replaces listing as a whole, PATCH only touches given fields
+		if r.Method == http.MethodPut {
+			listing = play.Listing{}
+		}
+		if err := json.Unmarshal(body, &listing); err != nil {
+			return nil, &apiError{http.StatusBadRequest, "invalid", err.Error()}
+		}
+		listing.Language = lang
+
+		e.state.listings[lang] = listing
+		return listing, nil
+	}
+
+	return nil, methodNotAllowed()
+}
+
+func (s *Server) handleImages(r *http.Request, e *edit, lang string, imageType play.EditImageType) (interface{}, *apiError) {
+	key := imageKey{lang, imageType}
+
+	switch r.Method {
+	case http.MethodGet:
+		return play.ImageList{Images: imagesOf(e.state, imageType, lang)}, nil
+	case http.MethodDelete:
+		deleted := imagesOf(e.state, imageType, lang)
+		delete(e.state.images, key)
+		return play.DeletedImages{Deleted: deleted}, nil
+	}
+
+	return nil, methodNotAllowed()
+}
+
+func (s *Server) deleteImage(e *edit, lang string, imageType play.EditImageType, imageId string) (interface{}, *apiError) {
+	key := imageKey{lang, imageType}
+	images := e.state.images[key]
+
+	for i, image := range images {
+		if image.Id == imageId {
+			e.state.images[key] = append(images[:i:i], images[i+1:]...)
+			return nil, nil
+		}
+	}
+
+	return nil, &apiError{http.StatusNotFound, "notFound", fmt.Sprintf("Image %s not found.", imageId)}
+}
+
+// handleUpload serves {packageName}/edits/{editId}/listings/{lang}/{imageType}
+func (s *Server) handleUpload(r *http.Request, parts []string) (interface{}, *apiError) {
+	if len(parts) != 6 || parts[1] != "edits" || parts[3] != "listings" {
+		return nil, &apiError{http.StatusNotFound, "notFound", "Not Found"}
+	}
+	if r.Method != http.MethodPost {
+		return nil, methodNotAllowed()
+	}
+
+	e, err := s.openEdit(parts[0], parts[2])
+	if err != nil {
+		return nil, err
+	}
+
+	data, readErr := ioutil.ReadAll(r.Body)
+	if readErr != nil {
+		return nil, &apiError{http.StatusBadRequest, "badRequest", readErr.Error()}
+	}
+
+	mimeType := http.DetectContentType(data)
+	if mimeType != "image/png" && mimeType != "image/jpeg" {
+		return nil, &apiError{http.StatusBadRequest, "imageInvalid", fmt.Sprintf("Unsupported image type %s.", mimeType)}
+	}
+
+	key := imageKey{parts[4], play.EditImageType(parts[5])}
+	image := s.newImage(data)
+	e.state.images[key] = append(e.state.images[key], image)
+
+	return map[string]play.Image{"image": image.Image}, nil
+}
+
+func methodNotAllowed() *apiError {
+	return &apiError{http.StatusMethodNotAllowed, "methodNotAllowed", "Method Not Allowed"}
+}
+
+func writeJson(w http.ResponseWriter, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(value)
+}
+
+func writeError(w http.ResponseWriter, err apiError) {
+	var body play.ApiError
+	body.ErrorDefinition.Code = err.status
+	body.ErrorDefinition.Message = err.message
+	if err.reason != "" {
+		body.ErrorDefinition.Errors = []play.ApiErrorDetail{{Domain: "androidpublisher", Reason: err.reason, Message: err.message}}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(err.status)
+	json.NewEncoder(w).Encode(body)
+}
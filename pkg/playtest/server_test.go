@@ -0,0 +1,142 @@
+package playtest
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yurykabanov/google-play-edit/pkg/play"
+)
+
+const packageName = "com.example.project"
+
+var (
+	token = &play.AccessToken{AccessToken: "access_token", TokenType: "Bearer"}
+
+	pngImage = []byte("\x89PNG\r\n\x1a\n0000IHDR")
+)
+
+func TestServer_EditLifecycle(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+
+	server.SetListing(packageName, play.Listing{Language: "en-US", Title: "Old title"})
+
+	api := server.Api()
+	ctx := context.Background()
+
+	edit, err := api.Edits.Insert(ctx, token, packageName)
+	require.NoError(t, err)
+
+	listing, err := api.Listings.Update(ctx, token, packageName, edit.Id, &play.Listing{Language: "en-US", Title: "New title"})
+	require.NoError(t, err)
+	assert.Equal(t, "en-US", listing.Language)
+
+	image, err := api.Images.Upload(ctx, token, packageName, edit.Id, "en-US", play.EditImagePhoneScreenshots, bytes.NewReader(pngImage))
+	require.NoError(t, err)
+	sum := sha1.Sum(pngImage)
+	assert.Equal(t, hex.EncodeToString(sum[:]), image.Sha1)
+
+	// Changes are invisible until edit is committed
+	assert.Equal(t, "Old title", server.Listings(packageName)[0].Title)
+	assert.Empty(t, server.Images(packageName, "en-US", play.EditImagePhoneScreenshots))
+
+	_, err = api.Edits.Commit(ctx, token, packageName, edit.Id)
+	require.NoError(t, err)
+
+	assert.Equal(t, "New title", server.Listings(packageName)[0].Title)
+	assert.Equal(t, []play.Image{*image}, server.Images(packageName, "en-US", play.EditImagePhoneScreenshots))
+
+	// Committed edit is gone
+	_, err = api.Edits.Get(ctx, token, packageName, edit.Id)
+	assert.True(t, play.IsNotFound(err))
+}
+
+func TestServer_EditExpired(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+
+	api := server.Api()
+	ctx := context.Background()
+
+	edit, err := api.Edits.Insert(ctx, token, packageName)
+	require.NoError(t, err)
+
+	server.ExpireEdit(edit.Id)
+
+	_, err = api.Listings.List(ctx, token, packageName, edit.Id)
+	assert.True(t, play.IsEditExpired(err))
+}
+
+func TestServer_InjectFault(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+
+	server.InjectFault(Fault{Method: http.MethodPost, Path: "/edits", Status: http.StatusServiceUnavailable, Times: 1})
+
+	api := server.Api(play.WithApiMiddleware(play.RetryMiddleware(2, time.Millisecond)))
+
+	_, err := api.Edits.Insert(play.AllowPostRetry(context.Background()), token, packageName)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"POST /androidpublisher/v3/applications/com.example.project/edits",
+		"POST /androidpublisher/v3/applications/com.example.project/edits",
+	}, server.Requests())
+
+	server.InjectFault(Fault{Path: "/listings", Status: http.StatusForbidden, Reason: "permissionDenied"})
+
+	_, err = api.Listings.List(context.Background(), token, packageName, "1")
+	assert.True(t, play.IsPermissionDenied(err))
+}
+
+func TestServer_RejectsUnauthorized(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+
+	_, err := server.Api().Edits.Insert(context.Background(), nil, packageName)
+
+	apiError, ok := err.(play.ApiError)
+	if assert.True(t, ok) {
+		assert.Equal(t, http.StatusUnauthorized, apiError.Code())
+	}
+}
+
+func TestServer_RejectsNonImages(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+
+	api := server.Api()
+	ctx := context.Background()
+
+	edit, err := api.Edits.Insert(ctx, token, packageName)
+	require.NoError(t, err)
+
+	_, err = api.Images.Upload(ctx, token, packageName, edit.Id, "en-US", play.EditImageIcon, bytes.NewReader([]byte("plain text")))
+	assert.Error(t, err)
+	assert.Empty(t, server.EditImages(edit.Id, "en-US", play.EditImageIcon))
+}
+
+func TestServer_DeleteEdit(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+
+	api := server.Api()
+	ctx := context.Background()
+
+	edit, err := api.Edits.Insert(ctx, token, packageName)
+	require.NoError(t, err)
+
+	err = api.Edits.Delete(ctx, token, packageName, edit.Id)
+	require.NoError(t, err)
+
+	assert.Empty(t, server.EditIds())
+	assert.Contains(t, server.Requests(), "DELETE /androidpublisher/v3/applications/com.example.project/edits/"+edit.Id)
+}
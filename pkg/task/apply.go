@@ -0,0 +1,217 @@
+package task
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/yurykabanov/google-play-edit/pkg/play"
+)
+
+// DriftError means remote state (or local files) differ from the ones the
+// plan was made against.
+type DriftError struct {
+	Drifts []string
+}
+
+func (err DriftError) Error() string {
+	return fmt.Sprintf("state has drifted since the plan was made:\n- %s", strings.Join(err.Drifts, "\n- "))
+}
+
+type Applier interface {
+	Apply(ctx context.Context, plan *Plan) error
+}
+
+type applier struct {
+	api         *play.Api
+	accessToken *play.AccessToken
+	packageName string
+	editId      string
+
+	// Options are shared with upsert (upload progress, expiry policy)
+	options *upsert
+}
+
+func NewApplier(
+	api *play.Api,
+	accessToken *play.AccessToken,
+	packageName string,
+	editId string,
+	opts ...UpsertOption,
+) *applier {
+	return &applier{
+		api:         api,
+		accessToken: accessToken,
+		packageName: packageName,
+		editId:      editId,
+
+		options: NewUpsert(api, accessToken, packageName, editId, opts...),
+	}
+}
+
+// Apply makes sure nothing has drifted and then performs planned changes.
+func (task *applier) Apply(ctx context.Context, plan *Plan) (err error) {
+	ctx, span := play.StartSpan(ctx, "task.apply",
+		play.PackageNameKey.String(task.packageName),
+		play.EditIdKey.String(task.editId),
+	)
+	defer func() { play.EndSpan(span, err) }()
+
+	if plan.PackageName != task.packageName {
+		return fmt.Errorf("plan is made for package %s, not %s", plan.PackageName, task.packageName)
+	}
+
+	remoteImages, err := task.checkDrift(ctx, plan)
+	if err != nil {
+		return err
+	}
+
+	for _, lang := range plan.DeleteLanguages {
+		err = task.api.Listings.Delete(ctx, task.accessToken, task.packageName, task.editId, lang)
+		if err != nil {
+			return fmt.Errorf("%s: %w", lang, err)
+		}
+	}
+
+	for _, listing := range plan.Listings {
+		err = task.applyListing(ctx, listing, remoteImages[listing.Language])
+		if err != nil {
+			return fmt.Errorf("%s: %w", listing.Language, err)
+		}
+	}
+
+	return nil
+}
+
+// checkDrift compares current state with the base state of plan and returns
+// current remote images by language and type.
+func (task *applier) checkDrift(ctx context.Context, plan *Plan) (map[string]map[play.EditImageType][]play.Image, error) {
+	var drifts []string
+
+	remoteListings, err := task.api.Listings.List(ctx, task.accessToken, task.packageName, task.editId)
+	if err != nil {
+		return nil, err
+	}
+
+	remote := make(map[string]*play.Listing)
+	languages := make([]string, 0, len(remoteListings))
+	for i := range remoteListings {
+		remote[remoteListings[i].Language] = &remoteListings[i]
+		languages = append(languages, remoteListings[i].Language)
+	}
+	sort.Strings(languages)
+
+	if strings.Join(languages, ",") != strings.Join(plan.BaseLanguages, ",") {
+		drifts = append(drifts, fmt.Sprintf("languages are [%s], planned for [%s]",
+			strings.Join(languages, ", "), strings.Join(plan.BaseLanguages, ", ")))
+	}
+
+	remoteImages := make(map[string]map[play.EditImageType][]play.Image)
+
+	for _, listing := range plan.Listings {
+		if !reflect.DeepEqual(remote[listing.Language], listing.Base) {
+			drifts = append(drifts, fmt.Sprintf("listing %s has changed", listing.Language))
+		}
+
+		remoteImages[listing.Language] = make(map[play.EditImageType][]play.Image)
+
+		for _, change := range listing.Images {
+			images, err := task.api.Images.List(ctx, task.accessToken, task.packageName, task.editId, listing.Language, change.ImageType)
+			if err != nil {
+				return nil, err
+			}
+			remoteImages[listing.Language][change.ImageType] = images
+
+			hashes := make([]string, 0, len(images))
+			for _, image := range images {
+				hashes = append(hashes, image.Sha1)
+			}
+			if strings.Join(hashes, ",") != strings.Join(change.Base, ",") {
+				drifts = append(drifts, fmt.Sprintf("%s of listing %s have changed", change.ImageType, listing.Language))
+			}
+
+			for _, upload := range change.Upload {
+				sha1sum, err := fileSha1(upload.Path)
+				if err != nil {
+					return nil, err
+				}
+				if sha1sum != upload.Sha1 {
+					drifts = append(drifts, fmt.Sprintf("file %s has changed", upload.Path))
+				}
+			}
+		}
+	}
+
+	if len(drifts) > 0 {
+		return nil, DriftError{Drifts: drifts}
+	}
+
+	return remoteImages, nil
+}
+
+func (task *applier) applyListing(ctx context.Context, listing ListingChange, remoteImages map[play.EditImageType][]play.Image) error {
+	err := task.options.checkExpiry()
+	if err != nil {
+		return err
+	}
+
+	if update := listing.Update; update != nil {
+		if listing.Base == nil {
+			_, err = task.api.Listings.Update(ctx, task.accessToken, task.packageName, task.editId, &update.Listing)
+		} else {
+			_, err = task.api.Listings.Patch(ctx, task.accessToken, task.packageName, task.editId, &update.Listing, update.Fields)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, change := range listing.Images {
+		images := remoteImages[change.ImageType]
+
+		for _, ref := range change.Delete {
+			err := task.api.Images.Delete(
+				ctx,
+				task.accessToken, task.packageName, task.editId,
+				listing.Language, change.ImageType, images[ref.Position].Id,
+			)
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, upload := range change.Upload {
+			err := task.options.checkExpiry()
+			if err != nil {
+				return err
+			}
+
+			err = task.uploadFile(ctx, listing.Language, change.ImageType, upload.Path)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (task *applier) uploadFile(ctx context.Context, lang string, imageType play.EditImageType, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = task.api.Images.Upload(
+		ctx,
+		task.accessToken, task.packageName, task.editId,
+		lang, imageType, f,
+		task.options.uploadOptions(lang, imageType, f)...,
+	)
+
+	return err
+}
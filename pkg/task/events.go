@@ -0,0 +1,86 @@
+package task
+
+import (
+	"io"
+	"time"
+
+	"github.com/yurykabanov/google-play-edit/pkg/play"
+)
+
+type EventType string
+
+const (
+	EventListingUpdated EventType = "listingUpdated"
+	EventImageDeleted   EventType = "imageDeleted"
+	EventImageUploaded  EventType = "imageUploaded"
+
+	// Image is already in place and left as is
+	EventImageSkipped EventType = "imageSkipped"
+
+	EventLanguageSynced EventType = "languageSynced"
+	EventLanguageFailed EventType = "languageFailed"
+)
+
+// Event is a step made by task. Duration is the time the step took, for
+// language events it is the time of the whole language.
+type Event struct {
+	Type     EventType     `json:"type"`
+	Time     time.Time     `json:"time"`
+	Duration time.Duration `json:"duration"`
+
+	Language  string              `json:"language"`
+	Fields    []play.ListingField `json:"fields,omitempty"`
+	ImageType play.EditImageType  `json:"imageType,omitempty"`
+	ImageId   string              `json:"imageId,omitempty"`
+	Sha1      string              `json:"sha1,omitempty"`
+
+	// Name of uploaded image when its reader has one (e.g. a file)
+	Name string `json:"name,omitempty"`
+
+	Error string `json:"error,omitempty"`
+
+	// Changes of dry run are only recorded, nothing is actually changed
+	DryRun bool `json:"dryRun,omitempty"`
+}
+
+// Observer receives events of tasks. It is called synchronously, and
+// concurrently when Sync has several workers.
+type Observer interface {
+	Observe(event Event)
+}
+
+type ObserverFunc func(event Event)
+
+func (f ObserverFunc) Observe(event Event) {
+	f(event)
+}
+
+// WithObserver subscribes observer to events, there could be several ones.
+func WithObserver(observer Observer) UpsertOption {
+	return func(task *upsert) {
+		task.observers = append(task.observers, observer)
+	}
+}
+
+// emit sends event started at given time to all observers.
+func (task *upsert) emit(event Event, started time.Time) {
+	if len(task.observers) == 0 {
+		return
+	}
+
+	event.Time = task.now()
+	event.Duration = event.Time.Sub(started)
+	event.DryRun = play.IsDryRunApi(task.api)
+
+	for _, observer := range task.observers {
+		observer.Observe(event)
+	}
+}
+
+func imageName(image io.ReadSeeker) string {
+	if named, ok := image.(interface{ Name() string }); ok {
+		return named.Name()
+	}
+
+	return ""
+}
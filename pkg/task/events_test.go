@@ -0,0 +1,101 @@
+package task
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/yurykabanov/google-play-edit/pkg/play"
+	"github.com/yurykabanov/google-play-edit/pkg/playtest"
+)
+
+type eventRecorder struct {
+	events chan Event
+}
+
+func (recorder *eventRecorder) Observe(event Event) {
+	recorder.events <- event
+}
+
+// types returns types of recorded events by language, no events are
+// expected afterwards.
+func (recorder *eventRecorder) types() map[string][]EventType {
+	close(recorder.events)
+
+	types := make(map[string][]EventType)
+	for event := range recorder.events {
+		types[event.Language] = append(types[event.Language], event.Type)
+	}
+
+	return types
+}
+
+func TestUpsert_RunEmitsEvents(t *testing.T) {
+	server := playtest.NewServer()
+	defer server.Close()
+
+	kept := []byte("\x89PNG\r\n\x1a\nkept")
+	server.AddImage(packageName, "zz-ZZ", play.EditImagePhoneScreenshots, kept)
+	server.AddImage(packageName, "zz-ZZ", play.EditImagePhoneScreenshots, []byte("\x89PNG\r\n\x1a\nold"))
+
+	api := server.Api()
+
+	edit, err := api.Edits.Insert(ctx, token, packageName)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	ch := make(chan io.ReadSeeker, 2)
+	ch <- bytes.NewReader(kept)
+	ch <- bytes.NewReader([]byte("\x89PNG\r\n\x1a\nnew"))
+	close(ch)
+
+	recorder := &eventRecorder{events: make(chan Event, 10)}
+
+	err = NewUpsert(api, token, packageName, edit.Id, WithObserver(recorder)).
+		Run(ctx, &play.Listing{Language: "zz-ZZ"}, nil, ImageTypeSources{play.EditImagePhoneScreenshots: ch})
+	assert.NoError(t, err)
+
+	assert.Equal(t, map[string][]EventType{
+		"zz-ZZ": {
+			EventListingUpdated,
+			EventImageDeleted,
+			EventImageSkipped,
+			EventImageUploaded,
+			EventLanguageSynced,
+		},
+	}, recorder.types())
+}
+
+func TestSync_RunEmitsEvents(t *testing.T) {
+	server := playtest.NewServer()
+	defer server.Close()
+
+	api := server.Api()
+
+	edit, err := api.Edits.Insert(ctx, token, packageName)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// Invalid image fails the second language
+	ch := make(chan io.ReadSeeker, 1)
+	ch <- bytes.NewReader([]byte("not an image"))
+	close(ch)
+
+	recorder := &eventRecorder{events: make(chan Event, 10)}
+
+	err = NewSync(api, token, packageName, edit.Id, WithWorkers(2), WithUpsertOptions(WithObserver(recorder))).
+		Run(ctx, []ListingWithImages{
+			{Listing: &play.Listing{Language: "aa-AA"}, ImageTypeSources: ImageTypeSources{}},
+			{Listing: &play.Listing{Language: "bb-BB"}, ImageTypeSources: ImageTypeSources{play.EditImagePhoneScreenshots: ch}},
+		}, false)
+	assert.Error(t, err)
+
+	assert.Equal(t, map[string][]EventType{
+		"aa-AA": {EventListingUpdated, EventLanguageSynced},
+		"bb-BB": {EventListingUpdated, EventLanguageFailed},
+	}, recorder.types())
+}
@@ -0,0 +1,60 @@
+package task
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// ExpiryPolicy defines how much of edit's lifetime has to be left before
+// expensive steps (listing update, image upload) are started.
+type ExpiryPolicy struct {
+	Expiry time.Time
+
+	// Steps are refused when less than MinLifetime is left
+	MinLifetime time.Duration
+
+	// Warn is called once when less than WarnLifetime is left
+	WarnLifetime time.Duration
+	Warn         func(left time.Duration)
+}
+
+type EditExpiresSoonError struct {
+	EditId string
+	Left   time.Duration
+}
+
+func (err EditExpiresSoonError) Error() string {
+	if err.Left <= 0 {
+		return fmt.Sprintf("edit %s is expired", err.EditId)
+	}
+
+	return fmt.Sprintf("edit %s expires in %s, which is too soon to continue", err.EditId, err.Left.Round(time.Second))
+}
+
+func WithExpiryPolicy(policy ExpiryPolicy) UpsertOption {
+	return func(task *upsert) {
+		task.expiryPolicy = &policy
+	}
+}
+
+// checkExpiry makes sure edit lives long enough for the next step.
+func (task *upsert) checkExpiry() error {
+	policy := task.expiryPolicy
+	if policy == nil || policy.Expiry.IsZero() {
+		return nil
+	}
+
+	left := policy.Expiry.Sub(task.now())
+
+	if left < policy.MinLifetime || left <= 0 {
+		return EditExpiresSoonError{EditId: task.editId, Left: left}
+	}
+
+	// Task could be shared by concurrent workers, so warning is guarded
+	if left < policy.WarnLifetime && policy.Warn != nil && atomic.CompareAndSwapInt32(&task.expiryWarned, 0, 1) {
+		policy.Warn(left)
+	}
+
+	return nil
+}
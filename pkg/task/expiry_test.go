@@ -0,0 +1,58 @@
+package task
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/yurykabanov/google-play-edit/pkg/play"
+	"github.com/yurykabanov/google-play-edit/pkg/playtest"
+)
+
+func TestUpsert_RunRefusesExpiringEdit(t *testing.T) {
+	api := &play.Api{
+		Edits:    &mockEditApi{},
+		Listings: &mockListingApi{},
+		Images:   &mockImagesApi{},
+	}
+
+	task := NewUpsert(api, token, packageName, editId, WithExpiryPolicy(ExpiryPolicy{
+		Expiry:      time.Now().Add(5 * time.Minute),
+		MinLifetime: 10 * time.Minute,
+	}))
+
+	// No API calls are expected at all
+	err := task.Run(ctx, &play.Listing{Language: "zz-ZZ"}, nil, ImageTypeSources{})
+	assert.IsType(t, EditExpiresSoonError{}, err)
+}
+
+func TestUpsert_RunWarnsAboutExpiringEdit(t *testing.T) {
+	server := playtest.NewServer()
+	defer server.Close()
+
+	api := server.Api()
+
+	edit, err := api.Edits.Insert(ctx, token, packageName)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.InDelta(t, playtest.DefaultEditLifetime.Seconds(), edit.TimeLeft(time.Now()).Seconds(), 5)
+
+	var warnings []time.Duration
+
+	task := NewUpsert(api, token, packageName, edit.Id, WithExpiryPolicy(ExpiryPolicy{
+		Expiry:       edit.Expiry,
+		MinLifetime:  time.Hour,
+		WarnLifetime: 30 * 24 * time.Hour,
+		Warn:         func(left time.Duration) { warnings = append(warnings, left) },
+	}))
+
+	for _, lang := range []string{"aa-AA", "bb-BB"} {
+		err = task.Run(ctx, &play.Listing{Language: lang}, nil, ImageTypeSources{})
+		assert.NoError(t, err)
+	}
+
+	assert.Len(t, warnings, 1)
+	assert.Len(t, server.EditListings(edit.Id), 2)
+}
@@ -0,0 +1,61 @@
+package task
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"io"
+	"os"
+)
+
+// imageDiff describes how to turn remote images into desired ones: remote
+// images at Delete positions are deleted, then desired images at Upload
+// positions are uploaded in order.
+type imageDiff struct {
+	Delete []int
+	Upload []int
+}
+
+// diffImages computes the minimal set of deletions and uploads turning remote
+// images into exactly desired ones, images are compared by sha1 hashes.
+//
+// It is a longest common subsequence constrained by API: uploaded images are
+// always appended, so kept remote images have to form a prefix of desired
+// ones. The longest such prefix is found greedily, all other remote images
+// (including surplus trailing ones) are deleted and the rest of desired images
+// is uploaded.
+func diffImages(remote []string, desired []string) imageDiff {
+	var diff imageDiff
+
+	kept := 0
+	for i, sha1sum := range remote {
+		if kept < len(desired) && desired[kept] == sha1sum {
+			kept++
+			continue
+		}
+
+		diff.Delete = append(diff.Delete, i)
+	}
+
+	for i := kept; i < len(desired); i++ {
+		diff.Upload = append(diff.Upload, i)
+	}
+
+	return diff
+}
+
+func fileSha1(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	hash := sha1.New()
+
+	_, err = io.Copy(hash, f)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
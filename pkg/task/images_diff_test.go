@@ -0,0 +1,36 @@
+package task
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffImages(t *testing.T) {
+	for _, test := range []struct {
+		remote  []string
+		desired []string
+		diff    imageDiff
+	}{
+		{[]string{"aaa", "bbb", "ccc", "ddd"}, []string{"aaa", "ccc", "eee"}, imageDiff{Delete: []int{1, 3}, Upload: []int{2}}},
+
+		// Surplus remote images are deleted
+		{[]string{"aaa", "bbb", "ccc"}, []string{"aaa", "bbb"}, imageDiff{Delete: []int{2}}},
+		{[]string{"aaa", "bbb"}, nil, imageDiff{Delete: []int{0, 1}}},
+
+		// Nothing to do
+		{[]string{"aaa", "bbb"}, []string{"aaa", "bbb"}, imageDiff{}},
+
+		// Appended image is the only upload
+		{[]string{"aaa", "bbb"}, []string{"aaa", "bbb", "ccc"}, imageDiff{Upload: []int{2}}},
+
+		// Moving the last image to the front keeps it, others are re-uploaded
+		// after it as uploads are always appended
+		{[]string{"aaa", "bbb", "ccc"}, []string{"ccc", "aaa", "bbb"}, imageDiff{Delete: []int{0, 1}, Upload: []int{1, 2}}},
+
+		// Moving the first image to the end re-uploads it only
+		{[]string{"aaa", "bbb", "ccc"}, []string{"bbb", "ccc", "aaa"}, imageDiff{Delete: []int{0}, Upload: []int{2}}},
+	} {
+		assert.Equal(t, test.diff, diffImages(test.remote, test.desired), "%v -> %v", test.remote, test.desired)
+	}
+}
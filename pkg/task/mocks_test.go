@@ -9,6 +9,15 @@ import (
 	"github.com/yurykabanov/google-play-edit/pkg/play"
 )
 
+// derivedCtx matches test's ctx and contexts derived from it, as tasks attach
+// tracing spans to the context they are given.
+var derivedCtx = mock.MatchedBy(func(c context.Context) bool {
+	expected, _ := ctx.Deadline()
+	actual, _ := c.Deadline()
+
+	return expected.Equal(actual)
+})
+
 type mockEditApi struct {
 	mock.Mock
 }
@@ -71,6 +80,12 @@ func (mock *mockListingApi) List(ctx context.Context, token *play.AccessToken, p
 	return args.Get(0).([]play.Listing), args.Error(1)
 }
 
+func (mock *mockListingApi) Patch(ctx context.Context, token *play.AccessToken, packageName string, editId string, listing *play.Listing, fields []play.ListingField) (*play.Listing, error) {
+	args := mock.Called(ctx, token, packageName, editId, listing, fields)
+
+	return args.Get(0).(*play.Listing), args.Error(1)
+}
+
 func (mock *mockListingApi) Update(ctx context.Context, token *play.AccessToken, packageName string, editId string, listing *play.Listing) (*play.Listing, error) {
 	args := mock.Called(ctx, token, packageName, editId, listing)
 
@@ -99,7 +114,7 @@ func (mock *mockImagesApi) List(ctx context.Context, token *play.AccessToken, pa
 	return args.Get(0).([]play.Image), args.Error(1)
 }
 
-func (mock *mockImagesApi) Upload(ctx context.Context, token *play.AccessToken, packageName string, editId string, lang string, imageType play.EditImageType, imageReader io.ReadSeeker) (*play.Image, error) {
+func (mock *mockImagesApi) Upload(ctx context.Context, token *play.AccessToken, packageName string, editId string, lang string, imageType play.EditImageType, imageReader io.ReadSeeker, opts ...play.UploadOption) (*play.Image, error) {
 	args := mock.Called(ctx, token, packageName, editId, lang, imageType, imageReader)
 
 	return args.Get(0).(*play.Image), args.Error(1)
@@ -0,0 +1,236 @@
+package task
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/yurykabanov/google-play-edit/pkg/play"
+)
+
+// DesiredListing is a listing as it should be after the plan is applied.
+type DesiredListing struct {
+	Listing play.Listing
+
+	// Fields to update, nil means all of them
+	Fields []play.ListingField
+
+	// Paths of image files by type in desired order, types without paths are
+	// left intact
+	Images map[play.EditImageType][]string
+}
+
+// Plan is a reviewable list of changes. It also holds remote state the plan
+// was made against, so that it is never applied to a different state.
+type Plan struct {
+	PackageName string    `json:"packageName"`
+	CreatedAt   time.Time `json:"createdAt"`
+
+	// Languages of remote listings at the time of planning
+	BaseLanguages []string `json:"baseLanguages"`
+
+	Listings        []ListingChange `json:"listings"`
+	DeleteLanguages []string        `json:"deleteLanguages,omitempty"`
+}
+
+type ListingChange struct {
+	Language string `json:"language"`
+
+	// Remote listing at the time of planning, nil if there was none
+	Base *play.Listing `json:"base"`
+
+	Update *ListingUpdate `json:"update,omitempty"`
+	Images []ImageChange  `json:"images,omitempty"`
+}
+
+type ListingUpdate struct {
+	Listing play.Listing        `json:"listing"`
+	Fields  []play.ListingField `json:"fields"`
+}
+
+type ImageChange struct {
+	ImageType play.EditImageType `json:"imageType"`
+
+	// Hashes of remote images at the time of planning
+	Base []string `json:"base"`
+
+	Delete []ImageRef    `json:"delete,omitempty"`
+	Upload []ImageUpload `json:"upload,omitempty"`
+}
+
+// ImageRef points to a remote image by its position in ImageChange.Base.
+type ImageRef struct {
+	Position int    `json:"position"`
+	Sha1     string `json:"sha1"`
+}
+
+type ImageUpload struct {
+	Path string `json:"path"`
+	Sha1 string `json:"sha1"`
+}
+
+// Empty reports whether applying the plan changes nothing.
+func (plan *Plan) Empty() bool {
+	if len(plan.DeleteLanguages) > 0 {
+		return false
+	}
+
+	for _, listing := range plan.Listings {
+		if listing.Update != nil {
+			return false
+		}
+		for _, images := range listing.Images {
+			if len(images.Delete) > 0 || len(images.Upload) > 0 {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+type Planner interface {
+	Plan(ctx context.Context, desired []DesiredListing, deleteMissing bool) (*Plan, error)
+}
+
+type planner struct {
+	api         *play.Api
+	accessToken *play.AccessToken
+	packageName string
+	editId      string
+}
+
+// NewPlanner makes planner comparing desired state with state of given edit,
+// the edit itself is never modified.
+func NewPlanner(
+	api *play.Api,
+	accessToken *play.AccessToken,
+	packageName string,
+	editId string,
+) *planner {
+	return &planner{
+		api:         api,
+		accessToken: accessToken,
+		packageName: packageName,
+		editId:      editId,
+	}
+}
+
+func (task *planner) Plan(ctx context.Context, desired []DesiredListing, deleteMissing bool) (plan *Plan, err error) {
+	ctx, span := play.StartSpan(ctx, "task.plan",
+		play.PackageNameKey.String(task.packageName),
+		play.EditIdKey.String(task.editId),
+	)
+	defer func() { play.EndSpan(span, err) }()
+
+	remoteListings, err := task.api.Listings.List(ctx, task.accessToken, task.packageName, task.editId)
+	if err != nil {
+		return nil, err
+	}
+
+	plan = &Plan{PackageName: task.packageName, CreatedAt: time.Now().UTC()}
+
+	remote := make(map[string]*play.Listing)
+	for i := range remoteListings {
+		remote[remoteListings[i].Language] = &remoteListings[i]
+		plan.BaseLanguages = append(plan.BaseLanguages, remoteListings[i].Language)
+	}
+	sort.Strings(plan.BaseLanguages)
+
+	desiredLanguages := make(map[string]bool)
+
+	for _, listing := range desired {
+		desiredLanguages[listing.Listing.Language] = true
+
+		change, err := task.planListing(ctx, listing, remote[listing.Listing.Language])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", listing.Listing.Language, err)
+		}
+
+		plan.Listings = append(plan.Listings, *change)
+	}
+
+	if deleteMissing {
+		for _, lang := range plan.BaseLanguages {
+			if !desiredLanguages[lang] {
+				plan.DeleteLanguages = append(plan.DeleteLanguages, lang)
+			}
+		}
+	}
+
+	return plan, nil
+}
+
+func (task *planner) planListing(ctx context.Context, listing DesiredListing, base *play.Listing) (*ListingChange, error) {
+	change := &ListingChange{Language: listing.Listing.Language, Base: base}
+
+	fields := listing.Fields
+	if fields == nil {
+		fields = play.ListingFields
+	}
+
+	var changed []play.ListingField
+	for _, field := range fields {
+		if base == nil || base.Field(field) != listing.Listing.Field(field) {
+			changed = append(changed, field)
+		}
+	}
+
+	if base == nil || len(changed) > 0 {
+		change.Update = &ListingUpdate{Listing: listing.Listing, Fields: changed}
+	}
+
+	imageTypes := make([]string, 0, len(listing.Images))
+	for imageType, paths := range listing.Images {
+		// Same as upsert, images of a type without desired ones are kept
+		if len(paths) == 0 {
+			continue
+		}
+		imageTypes = append(imageTypes, string(imageType))
+	}
+	sort.Strings(imageTypes)
+
+	for _, imageType := range imageTypes {
+		imageChange, err := task.planImages(ctx, listing.Listing.Language, play.EditImageType(imageType), listing.Images[play.EditImageType(imageType)])
+		if err != nil {
+			return nil, err
+		}
+
+		change.Images = append(change.Images, *imageChange)
+	}
+
+	return change, nil
+}
+
+func (task *planner) planImages(ctx context.Context, lang string, imageType play.EditImageType, paths []string) (*ImageChange, error) {
+	images, err := task.api.Images.List(ctx, task.accessToken, task.packageName, task.editId, lang, imageType)
+	if err != nil {
+		return nil, err
+	}
+
+	change := &ImageChange{ImageType: imageType, Base: make([]string, 0, len(images))}
+	for _, image := range images {
+		change.Base = append(change.Base, image.Sha1)
+	}
+
+	desired := make([]string, 0, len(paths))
+	for _, path := range paths {
+		sha1sum, err := fileSha1(path)
+		if err != nil {
+			return nil, err
+		}
+		desired = append(desired, sha1sum)
+	}
+
+	diff := diffImages(change.Base, desired)
+
+	for _, i := range diff.Delete {
+		change.Delete = append(change.Delete, ImageRef{Position: i, Sha1: change.Base[i]})
+	}
+	for _, i := range diff.Upload {
+		change.Upload = append(change.Upload, ImageUpload{Path: paths[i], Sha1: desired[i]})
+	}
+
+	return change, nil
+}
@@ -0,0 +1,87 @@
+package task
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yurykabanov/google-play-edit/pkg/play"
+	"github.com/yurykabanov/google-play-edit/pkg/playtest"
+)
+
+func pngFile(t *testing.T, dir string, name string) string {
+	path := filepath.Join(dir, name)
+	require.NoError(t, ioutil.WriteFile(path, []byte("\x89PNG\r\n\x1a\n"+name), 0644))
+
+	return path
+}
+
+func TestPlanner_PlanAndApply(t *testing.T) {
+	server := playtest.NewServer()
+	defer server.Close()
+
+	dir := t.TempDir()
+	first, second := pngFile(t, dir, "1.png"), pngFile(t, dir, "2.png")
+	stale, _ := ioutil.ReadFile(pngFile(t, dir, "stale.png"))
+	firstData, _ := ioutil.ReadFile(first)
+
+	server.SetListing(packageName, play.Listing{Language: "aa-AA", Title: "Title", Video: "https://youtu.be/video"})
+	server.SetListing(packageName, play.Listing{Language: "cc-CC", Title: "Obsolete"})
+	server.AddImage(packageName, "aa-AA", play.EditImagePhoneScreenshots, firstData)
+	server.AddImage(packageName, "aa-AA", play.EditImagePhoneScreenshots, stale)
+
+	api := server.Api()
+
+	edit, err := api.Edits.Insert(ctx, token, packageName)
+	require.NoError(t, err)
+
+	plan, err := NewPlanner(api, token, packageName, edit.Id).Plan(ctx, []DesiredListing{
+		{
+			Listing: play.Listing{Language: "aa-AA", Title: "New title"},
+			Fields:  []play.ListingField{play.ListingTitle},
+			Images:  map[play.EditImageType][]string{play.EditImagePhoneScreenshots: {first, second}},
+		},
+		{
+			Listing: play.Listing{Language: "bb-BB", Title: "Title"},
+		},
+	}, true)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"aa-AA", "cc-CC"}, plan.BaseLanguages)
+	assert.Equal(t, []string{"cc-CC"}, plan.DeleteLanguages)
+	assert.Equal(t, []play.ListingField{play.ListingTitle}, plan.Listings[0].Update.Fields)
+	assert.Equal(t, []ImageRef{{Position: 1, Sha1: plan.Listings[0].Images[0].Base[1]}}, plan.Listings[0].Images[0].Delete)
+	assert.Equal(t, second, plan.Listings[0].Images[0].Upload[0].Path)
+	assert.Equal(t, play.ListingFields, plan.Listings[1].Update.Fields)
+
+	// Planning leaves edit intact
+	assert.Len(t, server.EditListings(edit.Id), 2)
+
+	// Plan survives serialization
+	data, err := json.Marshal(plan)
+	require.NoError(t, err)
+
+	var decoded Plan
+	require.NoError(t, json.Unmarshal(data, &decoded))
+
+	err = NewApplier(api, token, packageName, edit.Id).Apply(ctx, &decoded)
+	require.NoError(t, err)
+
+	assert.Equal(t, []play.Listing{
+		{Language: "aa-AA", Title: "New title", Video: "https://youtu.be/video"},
+		{Language: "bb-BB", Title: "Title"},
+	}, server.EditListings(edit.Id))
+
+	images := server.EditImages(edit.Id, "aa-AA", play.EditImagePhoneScreenshots)
+	if assert.Len(t, images, 2) {
+		assert.Equal(t, plan.Listings[0].Images[0].Upload[0].Sha1, images[1].Sha1)
+	}
+
+	// The same plan can't be applied twice
+	err = NewApplier(api, token, packageName, edit.Id).Apply(ctx, &decoded)
+	assert.IsType(t, DriftError{}, err)
+}
@@ -2,12 +2,19 @@ package task
 
 import (
 	"context"
+	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/yurykabanov/google-play-edit/pkg/play"
 )
 
 type ListingWithImages struct {
-	Listing          *play.Listing
+	Listing *play.Listing
+
+	// Fields to update, nil means all of them
+	Fields []play.ListingField
+
 	ImageTypeSources ImageTypeSources
 }
 
@@ -21,7 +28,29 @@ type sync struct {
 	packageName string
 	editId      string
 
-	upsert Upsert
+	upsert  Upsert
+	workers int
+}
+
+type SyncOption func(task *sync, upsertOpts *[]UpsertOption)
+
+// WithWorkers sets number of languages processed concurrently, images of a
+// single language are always processed sequentially.
+func WithWorkers(workers int) SyncOption {
+	return func(task *sync, upsertOpts *[]UpsertOption) {
+		if workers > 0 {
+			task.workers = workers
+		}
+	}
+}
+
+// WithUpsertOptions configures upsert of every language (e.g. WithObserver to
+// receive events of all languages), note that callbacks are called
+// concurrently when there are several workers.
+func WithUpsertOptions(opts ...UpsertOption) SyncOption {
+	return func(task *sync, upsertOpts *[]UpsertOption) {
+		*upsertOpts = append(*upsertOpts, opts...)
+	}
 }
 
 func NewSync(
@@ -29,18 +58,71 @@ func NewSync(
 	accessToken *play.AccessToken,
 	packageName string,
 	editId string,
+	opts ...SyncOption,
 ) *sync {
-	return &sync{
+	task := &sync{
 		api:         api,
 		accessToken: accessToken,
 		packageName: packageName,
 		editId:      editId,
+		workers:     1,
+	}
 
-		upsert: NewUpsert(api, accessToken, packageName, editId),
+	var upsertOpts []UpsertOption
+	for _, opt := range opts {
+		opt(task, &upsertOpts)
 	}
+
+	task.upsert = NewUpsert(api, accessToken, packageName, editId, upsertOpts...)
+
+	return task
 }
 
-func (task *sync) Run(ctx context.Context, targetListingsWithImages []ListingWithImages, delete bool) error {
+// LanguageError is a failure to sync a single language.
+type LanguageError struct {
+	Language string
+	Err      error
+}
+
+func (err LanguageError) Error() string {
+	return fmt.Sprintf("%s: %s", err.Language, err.Err)
+}
+
+func (err LanguageError) Unwrap() error {
+	return err.Err
+}
+
+// SyncError lists all languages failed to sync, other languages are synced
+// successfully.
+type SyncError struct {
+	Errors []LanguageError
+}
+
+func (err SyncError) Error() string {
+	messages := make([]string, 0, len(err.Errors))
+	for _, e := range err.Errors {
+		messages = append(messages, e.Error())
+	}
+
+	return fmt.Sprintf("unable to sync %d language(s): %s", len(err.Errors), strings.Join(messages, "; "))
+}
+
+func (err SyncError) Unwrap() []error {
+	errs := make([]error, 0, len(err.Errors))
+	for _, e := range err.Errors {
+		errs = append(errs, e)
+	}
+
+	return errs
+}
+
+func (task *sync) Run(ctx context.Context, targetListingsWithImages []ListingWithImages, delete bool) (err error) {
+	ctx, span := play.StartSpan(ctx, "task.sync",
+		play.PackageNameKey.String(task.packageName),
+		play.EditIdKey.String(task.editId),
+	)
+	defer func() { play.EndSpan(span, err) }()
+
 	if delete {
 		originalListings, err := task.api.Listings.List(ctx, task.accessToken, task.packageName, task.editId)
 		if err != nil {
@@ -62,12 +144,70 @@ func (task *sync) Run(ctx context.Context, targetListingsWithImages []ListingWit
 		}
 	}
 
-	for _, lwi := range targetListingsWithImages {
-		err := task.upsert.Run(ctx, lwi.Listing, lwi.ImageTypeSources)
-		if err != nil {
-			return err
+	return task.upsertAll(ctx, targetListingsWithImages)
+}
+
+// upsertAll processes languages by pool of workers and collects their errors.
+// Once ctx is cancelled no more languages are started and ctx.Err() is
+// returned.
+func (task *sync) upsertAll(ctx context.Context, targetListingsWithImages []ListingWithImages) error {
+	jobs := make(chan ListingWithImages)
+	results := make(chan LanguageError)
+	finished := make(chan struct{})
+
+	workers := task.workers
+	if workers > len(targetListingsWithImages) {
+		workers = len(targetListingsWithImages)
+	}
+
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer func() { finished <- struct{}{} }()
+
+			for lwi := range jobs {
+				if ctx.Err() != nil {
+					continue
+				}
+
+				err := task.upsert.Run(ctx, lwi.Listing, lwi.Fields, lwi.ImageTypeSources)
+				results <- LanguageError{Language: lwi.Listing.Language, Err: err}
+			}
+		}()
+	}
+
+	go func() {
+		defer close(jobs)
+
+		for _, lwi := range targetListingsWithImages {
+			select {
+			case jobs <- lwi:
+			case <-ctx.Done():
+				return
+			}
 		}
+	}()
+
+	var syncErr SyncError
+	for workers > 0 {
+		select {
+		case result := <-results:
+			if result.Err != nil {
+				syncErr.Errors = append(syncErr.Errors, result)
+			}
+		case <-finished:
+			workers--
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
 	}
 
-	return nil
+	if len(syncErr.Errors) == 0 {
+		return nil
+	}
+
+	sort.Slice(syncErr.Errors, func(i, j int) bool { return syncErr.Errors[i].Language < syncErr.Errors[j].Language })
+
+	return syncErr
 }
@@ -2,6 +2,7 @@ package task
 
 import (
 	"context"
+	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -14,8 +15,8 @@ type upsertMock struct {
 	mock.Mock
 }
 
-func (mock *upsertMock) Run(ctx context.Context, listing *play.Listing, imageTypeSources ImageTypeSources) error {
-	args := mock.Called(ctx, listing, imageTypeSources)
+func (mock *upsertMock) Run(ctx context.Context, listing *play.Listing, fields []play.ListingField, imageTypeSources ImageTypeSources) error {
+	args := mock.Called(ctx, listing, fields, imageTypeSources)
 
 	return args.Error(0)
 }
@@ -47,19 +48,19 @@ func TestSync_Run(t *testing.T) {
 	}
 
 	// It should list existing listings
-	listingApi.On("List", ctx, token, packageName, editId).
+	listingApi.On("List", derivedCtx, token, packageName, editId).
 		Return(originalListings, nil).
 		Times(1)
 
 	// It should delete language that is not on the list
-	listingApi.On("Delete", ctx, token, packageName, editId, "cc-CC").
+	listingApi.On("Delete", derivedCtx, token, packageName, editId, "cc-CC").
 		Return(nil).
 		Times(1)
 
-	upsert.On("Run", ctx, targetListings[0].Listing, targetListings[0].ImageTypeSources).
+	upsert.On("Run", derivedCtx, targetListings[0].Listing, targetListings[0].Fields, targetListings[0].ImageTypeSources).
 		Return(nil).
 		Times(1)
-	upsert.On("Run", ctx, targetListings[1].Listing, targetListings[1].ImageTypeSources).
+	upsert.On("Run", derivedCtx, targetListings[1].Listing, targetListings[1].Fields, targetListings[1].ImageTypeSources).
 		Return(nil).
 		Times(1)
 
@@ -67,3 +68,77 @@ func TestSync_Run(t *testing.T) {
 
 	assert.Nil(t, err, "error should be nil")
 }
+
+func TestSync_RunCollectsLanguageErrors(t *testing.T) {
+	api := &play.Api{
+		Edits:    &mockEditApi{},
+		Listings: &mockListingApi{},
+		Images:   &mockImagesApi{},
+	}
+
+	upsert := &upsertMock{}
+
+	task := NewSync(api, token, packageName, editId, WithWorkers(3))
+	task.upsert = upsert
+
+	var targetListings []ListingWithImages
+	for _, lang := range []string{"aa-AA", "bb-BB", "cc-CC", "dd-DD", "ee-EE"} {
+		targetListings = append(targetListings, ListingWithImages{Listing: &play.Listing{Language: lang}})
+	}
+
+	failure := errors.New("failure")
+
+	// Failure of one language doesn't prevent others from being synced
+	for _, lwi := range targetListings {
+		var err error
+		if lwi.Listing.Language == "cc-CC" || lwi.Listing.Language == "aa-AA" {
+			err = failure
+		}
+
+		upsert.On("Run", derivedCtx, lwi.Listing, lwi.Fields, lwi.ImageTypeSources).
+			Return(err).
+			Times(1)
+	}
+
+	err := task.Run(ctx, targetListings, false)
+
+	syncErr, ok := err.(SyncError)
+	if assert.True(t, ok) {
+		assert.Equal(t, []LanguageError{{"aa-AA", failure}, {"cc-CC", failure}}, syncErr.Errors)
+	}
+	assert.True(t, errors.Is(err, failure))
+
+	upsert.AssertExpectations(t)
+}
+
+func TestSync_RunStopsOnCancel(t *testing.T) {
+	api := &play.Api{
+		Edits:    &mockEditApi{},
+		Listings: &mockListingApi{},
+		Images:   &mockImagesApi{},
+	}
+
+	upsert := &upsertMock{}
+
+	task := NewSync(api, token, packageName, editId)
+	task.upsert = upsert
+
+	var targetListings []ListingWithImages
+	for _, lang := range []string{"aa-AA", "bb-BB", "cc-CC"} {
+		targetListings = append(targetListings, ListingWithImages{Listing: &play.Listing{Language: lang}})
+	}
+
+	cancelledCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Interrupted while the first language is synced, others are not started
+	upsert.On("Run", derivedCtx, targetListings[0].Listing, targetListings[0].Fields, targetListings[0].ImageTypeSources).
+		Run(func(mock.Arguments) { cancel() }).
+		Return(context.Canceled).
+		Times(1)
+
+	err := task.Run(cancelledCtx, targetListings, false)
+	assert.Equal(t, context.Canceled, err)
+
+	upsert.AssertExpectations(t)
+}
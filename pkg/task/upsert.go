@@ -6,12 +6,16 @@ import (
 	"crypto/sha1"
 	"encoding/hex"
 	"io"
+	"sort"
+	"time"
 
 	"github.com/yurykabanov/google-play-edit/pkg/play"
 )
 
 type Upsert interface {
-	Run(ctx context.Context, listing *play.Listing, imageTypeSources ImageTypeSources) error
+	// Run updates fields of listing (all of them when fields is nil) and
+	// synchronizes its images.
+	Run(ctx context.Context, listing *play.Listing, fields []play.ListingField, imageTypeSources ImageTypeSources) error
 }
 
 type upsert struct {
@@ -19,6 +23,24 @@ type upsert struct {
 	accessToken *play.AccessToken
 	packageName string
 	editId      string
+
+	uploadProgress UploadProgressFunc
+	observers      []Observer
+
+	expiryPolicy *ExpiryPolicy
+	expiryWarned int32
+	now          func() time.Time
+}
+
+// UploadProgressFunc receives progress of every image upload made by task.
+type UploadProgressFunc func(lang string, imageType play.EditImageType, image io.ReadSeeker, progress play.UploadProgress)
+
+type UpsertOption func(task *upsert)
+
+func WithUploadProgress(progress UploadProgressFunc) UpsertOption {
+	return func(task *upsert) {
+		task.uploadProgress = progress
+	}
 }
 
 func NewUpsert(
@@ -26,25 +48,80 @@ func NewUpsert(
 	accessToken *play.AccessToken,
 	packageName string,
 	editId string,
+	opts ...UpsertOption,
 ) *upsert {
-	return &upsert{
+	task := &upsert{
 		api:         api,
 		accessToken: accessToken,
 		packageName: packageName,
 		editId:      editId,
+		now:         time.Now,
 	}
+
+	for _, opt := range opts {
+		opt(task)
+	}
+
+	return task
 }
 
+// ImageTypeSources gives desired images by type. Remote images of a type are
+// reconciled only when its source yields at least one image, so that missing
+// or empty sources never wipe them. Readers that are also io.Closers (e.g.
+// files) are closed by task once they are uploaded or skipped.
 type ImageTypeSources map[play.EditImageType]<-chan io.ReadSeeker
 
-func (task *upsert) Run(ctx context.Context, listing *play.Listing, imageTypeSources ImageTypeSources) error {
-	_, err := task.api.Listings.Update(ctx, task.accessToken, task.packageName, task.editId, listing)
+func (task *upsert) Run(ctx context.Context, listing *play.Listing, fields []play.ListingField, imageTypeSources ImageTypeSources) (err error) {
+	ctx, span := play.StartSpan(ctx, "task.upsert",
+		play.PackageNameKey.String(task.packageName),
+		play.EditIdKey.String(task.editId),
+		play.LanguageKey.String(listing.Language),
+	)
+	defer func() { play.EndSpan(span, err) }()
+
+	started := task.now()
+	defer func() {
+		if err != nil {
+			task.emit(Event{Type: EventLanguageFailed, Language: listing.Language, Error: err.Error()}, started)
+		} else {
+			task.emit(Event{Type: EventLanguageSynced, Language: listing.Language}, started)
+		}
+	}()
+
+	err = task.checkExpiry()
+	if err != nil {
+		return err
+	}
+
+	updated := task.now()
+	if fields == nil {
+		_, err = task.api.Listings.Update(ctx, task.accessToken, task.packageName, task.editId, listing)
+	} else {
+		_, err = task.api.Listings.Patch(ctx, task.accessToken, task.packageName, task.editId, listing, fields)
+
+		// Language is new to the edit, there is nothing to patch yet
+		if play.IsNotFound(err) {
+			fields = nil
+			_, err = task.api.Listings.Update(ctx, task.accessToken, task.packageName, task.editId, listing)
+		}
+	}
 	if err != nil {
 		return err
 	}
 
-	for imageType, imageChan := range imageTypeSources {
-		err = task.handleImageType(ctx, listing, imageType, imageChan)
+	if fields == nil {
+		fields = play.ListingFields
+	}
+	task.emit(Event{Type: EventListingUpdated, Language: listing.Language, Fields: fields}, updated)
+
+	imageTypes := make([]string, 0, len(imageTypeSources))
+	for imageType := range imageTypeSources {
+		imageTypes = append(imageTypes, string(imageType))
+	}
+	sort.Strings(imageTypes)
+
+	for _, imageType := range imageTypes {
+		err = task.handleImageType(ctx, listing, play.EditImageType(imageType), imageTypeSources[play.EditImageType(imageType)])
 		if err != nil {
 			return err
 		}
@@ -53,7 +130,15 @@ func (task *upsert) Run(ctx context.Context, listing *play.Listing, imageTypeSou
 	return nil
 }
 
-func (task *upsert) handleImageType(ctx context.Context, listing *play.Listing, imageType play.EditImageType, imagesChan <-chan io.ReadSeeker) error {
+func (task *upsert) handleImageType(ctx context.Context, listing *play.Listing, imageType play.EditImageType, imagesChan <-chan io.ReadSeeker) (err error) {
+	ctx, span := play.StartSpan(ctx, "task.upsert.images",
+		play.PackageNameKey.String(task.packageName),
+		play.EditIdKey.String(task.editId),
+		play.LanguageKey.String(listing.Language),
+		play.ImageTypeKey.String(string(imageType)),
+	)
+	defer func() { play.EndSpan(span, err) }()
+
 	images, err := task.api.Images.List(
 		ctx,
 		task.accessToken, task.packageName, task.editId,
@@ -63,38 +148,132 @@ func (task *upsert) handleImageType(ctx context.Context, listing *play.Listing,
 		return err
 	}
 
-	imagesCount := len(images)
-	pos := 0
+	remote := make([]string, 0, len(images))
+	for _, image := range images {
+		remote = append(remote, image.Sha1)
+	}
+
+	var readers []io.ReadSeeker
+	var desired []string
+
+	// Readers left open on early return are closed here
+	defer func() {
+		for i := range readers {
+			closeImage(&readers[i])
+		}
+	}()
+
+	for {
+		var imageReader io.ReadSeeker
+		var ok bool
+
+		select {
+		case imageReader, ok = <-imagesChan:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if !ok {
+			break
+		}
+
+		readers = append(readers, imageReader)
 
-	for imageReader := range imagesChan {
 		sha1sum, err := task.sha1sum(imageReader)
 		if err != nil {
 			return err
 		}
 
-		if pos < imagesCount {
-			n, err := task.deleteImagesUntilSha1(ctx, images[pos:], sha1sum, listing, imageType)
-			if err != nil {
-				return err
-			}
-			pos += n
+		desired = append(desired, sha1sum)
+	}
+
+	if len(desired) == 0 {
+		return nil
+	}
+
+	diff := diffImages(remote, desired)
+
+	deleted := make(map[int]bool)
+	for _, i := range diff.Delete {
+		// Stop as soon as possible when cancelled, not at the next request
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		started := task.now()
+		err = task.api.Images.Delete(
+			ctx,
+			task.accessToken, task.packageName, task.editId,
+			listing.Language, imageType, images[i].Id,
+		)
+		if err != nil {
+			return err
+		}
+
+		deleted[i] = true
+		task.emit(Event{
+			Type: EventImageDeleted, Language: listing.Language, ImageType: imageType,
+			ImageId: images[i].Id, Sha1: images[i].Sha1,
+		}, started)
+	}
 
+	// Remote images left after deletions are the kept prefix of desired ones
+	kept := 0
+	for i, image := range images {
+		if deleted[i] {
 			continue
 		}
 
-		_, err = task.api.Images.Upload(
+		task.emit(Event{
+			Type: EventImageSkipped, Language: listing.Language, ImageType: imageType,
+			ImageId: image.Id, Sha1: image.Sha1, Name: imageName(readers[kept]),
+		}, task.now())
+		closeImage(&readers[kept])
+		kept++
+	}
+
+	for _, i := range diff.Upload {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		err = task.checkExpiry()
+		if err != nil {
+			return err
+		}
+
+		started := task.now()
+		image, err := task.api.Images.Upload(
 			ctx,
 			task.accessToken, task.packageName, task.editId,
-			listing.Language, imageType, imageReader,
+			listing.Language, imageType, readers[i],
+			task.uploadOptions(listing.Language, imageType, readers[i])...,
 		)
 		if err != nil {
 			return err
 		}
+
+		task.emit(Event{
+			Type: EventImageUploaded, Language: listing.Language, ImageType: imageType,
+			ImageId: image.Id, Sha1: desired[i], Name: imageName(readers[i]),
+		}, started)
+		closeImage(&readers[i])
 	}
 
 	return nil
 }
 
+func (task *upsert) uploadOptions(lang string, imageType play.EditImageType, image io.ReadSeeker) []play.UploadOption {
+	if task.uploadProgress == nil {
+		return nil
+	}
+
+	return []play.UploadOption{
+		play.WithUploadProgress(func(progress play.UploadProgress) {
+			task.uploadProgress(lang, imageType, image, progress)
+		}),
+	}
+}
+
 func (task *upsert) sha1sum(r io.ReadSeeker) (string, error) {
 	hash := sha1.New()
 
@@ -112,39 +291,12 @@ func (task *upsert) sha1sum(r io.ReadSeeker) (string, error) {
 	return hex.EncodeToString(hash.Sum(nil)), nil
 }
 
-func (task *upsert) deleteImagesUntilSha1(
-	ctx context.Context,
-	images []play.Image,
-	sha1sum string,
-	listing *play.Listing,
-	imageType play.EditImageType,
-) (int, error) {
-	pos := 0
-	imagesCount := len(images)
-
-	for {
-		if pos >= imagesCount {
-			break
-		}
-
-		image := &images[pos]
-
-		if sha1sum == image.Sha1 {
-			pos++
-			break
-		} else {
-			err := task.api.Images.Delete(
-				ctx,
-				task.accessToken, task.packageName, task.editId,
-				listing.Language, imageType, image.Id,
-			)
-			if err != nil {
-				return 0, err
-			}
-
-			pos++
-		}
+// closeImage closes image if it is an io.Closer and forgets it, so that it is
+// never closed twice.
+func closeImage(image *io.ReadSeeker) {
+	if closer, ok := (*image).(io.Closer); ok {
+		closer.Close()
 	}
 
-	return pos, nil
+	*image = nil
 }
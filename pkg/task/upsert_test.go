@@ -3,6 +3,7 @@ package task
 import (
 	"bytes"
 	"context"
+	"errors"
 	"io"
 	"testing"
 	"time"
@@ -10,6 +11,7 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"github.com/yurykabanov/google-play-edit/pkg/play"
+	"github.com/yurykabanov/google-play-edit/pkg/playtest"
 )
 
 var (
@@ -56,11 +58,11 @@ func TestUpsert_Run(t *testing.T) {
 	close(ch)
 
 	// It should update edit's listing
-	listingApi.On("Update", ctx, token, packageName, editId, listing).Return(&play.Listing{}, nil).
+	listingApi.On("Update", derivedCtx, token, packageName, editId, listing).Return(&play.Listing{}, nil).
 		Times(1)
 
 	// It will eventually query list of images (to compare them with given images)
-	imagesApi.On("List", ctx, token, packageName, editId, listing.Language, play.EditImagePhoneScreenshots).
+	imagesApi.On("List", derivedCtx, token, packageName, editId, listing.Language, play.EditImagePhoneScreenshots).
 		Return([]play.Image{
 			{Id: "id_aaa", Sha1: sha1hashes["aaa"]},
 			{Id: "id_bbb", Sha1: sha1hashes["bbb"]},
@@ -75,26 +77,304 @@ func TestUpsert_Run(t *testing.T) {
 	//
 	// Task should
 	// - delete "bbb"
-	// - delete "ccc"
+	// - delete "ddd"
 	// - upload "eee"
 	// and should not touch
 	// - "aaa"
 	// - "ccc"
 	// as they are present in the same order in both original and target lists
 
-	imagesApi.On("Delete", ctx, token, packageName, editId, listing.Language, play.EditImagePhoneScreenshots, "id_bbb").
+	imagesApi.On("Delete", derivedCtx, token, packageName, editId, listing.Language, play.EditImagePhoneScreenshots, "id_bbb").
 		Return(nil).
 		Times(1)
-	imagesApi.On("Delete", ctx, token, packageName, editId, listing.Language, play.EditImagePhoneScreenshots, "id_ddd").
+	imagesApi.On("Delete", derivedCtx, token, packageName, editId, listing.Language, play.EditImagePhoneScreenshots, "id_ddd").
 		Return(nil).
 		Times(1)
-	imagesApi.On("Upload", ctx, token, packageName, editId, listing.Language, play.EditImagePhoneScreenshots, images[2]).
+	imagesApi.On("Upload", derivedCtx, token, packageName, editId, listing.Language, play.EditImagePhoneScreenshots, images[2]).
 		Return(&play.Image{}, nil).
 		Times(1)
 
-	err := task.Run(ctx, listing, ImageTypeSources{
+	err := task.Run(ctx, listing, nil, ImageTypeSources{
 		play.EditImagePhoneScreenshots: ch,
 	})
 
 	assert.Nil(t, err, "error should be nil")
+
+	imagesApi.AssertExpectations(t)
+}
+
+func TestUpsert_RunDeletesTrailingImages(t *testing.T) {
+	server := playtest.NewServer()
+	defer server.Close()
+
+	var data [][]byte
+	for _, name := range []string{"aaa", "bbb", "ccc"} {
+		data = append(data, []byte("\x89PNG\r\n\x1a\n"+name))
+		server.AddImage(packageName, "zz-ZZ", play.EditImagePhoneScreenshots, data[len(data)-1])
+	}
+
+	api := server.Api()
+
+	edit, err := api.Edits.Insert(ctx, token, packageName)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// The last screenshot is removed
+	ch := make(chan io.ReadSeeker, 2)
+	ch <- bytes.NewReader(data[0])
+	ch <- bytes.NewReader(data[1])
+	close(ch)
+
+	err = NewUpsert(api, token, packageName, edit.Id).
+		Run(ctx, &play.Listing{Language: "zz-ZZ"}, nil, ImageTypeSources{play.EditImagePhoneScreenshots: ch})
+	assert.NoError(t, err)
+
+	remote := server.Images(packageName, "zz-ZZ", play.EditImagePhoneScreenshots)
+	assert.Equal(t, remote[:2], server.EditImages(edit.Id, "zz-ZZ", play.EditImagePhoneScreenshots))
+}
+
+type closingReader struct {
+	*bytes.Reader
+	closed int
+}
+
+func (r *closingReader) Close() error {
+	r.closed++
+	return nil
+}
+
+func TestUpsert_RunClosesImages(t *testing.T) {
+	server := playtest.NewServer()
+	defer server.Close()
+
+	kept := []byte("\x89PNG\r\n\x1a\nkept")
+	server.AddImage(packageName, "zz-ZZ", play.EditImagePhoneScreenshots, kept)
+
+	api := server.Api()
+
+	edit, err := api.Edits.Insert(ctx, token, packageName)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// The first screenshot is skipped, the second one is uploaded
+	readers := []*closingReader{
+		{Reader: bytes.NewReader(kept)},
+		{Reader: bytes.NewReader([]byte("\x89PNG\r\n\x1a\nnew"))},
+	}
+
+	ch := make(chan io.ReadSeeker, len(readers))
+	for _, r := range readers {
+		ch <- r
+	}
+	close(ch)
+
+	err = NewUpsert(api, token, packageName, edit.Id).
+		Run(ctx, &play.Listing{Language: "zz-ZZ"}, nil, ImageTypeSources{play.EditImagePhoneScreenshots: ch})
+	assert.NoError(t, err)
+
+	for _, r := range readers {
+		assert.Equal(t, 1, r.closed)
+	}
+}
+
+func TestUpsert_RunAgainstFakeServer(t *testing.T) {
+	server := playtest.NewServer()
+	defer server.Close()
+
+	api := server.Api()
+
+	edit, err := api.Edits.Insert(ctx, token, packageName)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	ch := make(chan io.ReadSeeker, 2)
+	ch <- bytes.NewReader([]byte("\x89PNG\r\n\x1a\nfirst"))
+	ch <- bytes.NewReader([]byte("\x89PNG\r\n\x1a\nsecond"))
+	close(ch)
+
+	listing := &play.Listing{Language: "zz-ZZ", Title: "Title"}
+
+	err = NewUpsert(api, token, packageName, edit.Id).
+		Run(ctx, listing, nil, ImageTypeSources{play.EditImagePhoneScreenshots: ch})
+	assert.NoError(t, err)
+
+	assert.Equal(t, []play.Listing{*listing}, server.EditListings(edit.Id))
+	assert.Len(t, server.EditImages(edit.Id, listing.Language, play.EditImagePhoneScreenshots), 2)
+}
+
+func TestUpsert_RunCancelled(t *testing.T) {
+	server := playtest.NewServer()
+	defer server.Close()
+
+	for _, name := range []string{"old1", "old2"} {
+		server.AddImage(packageName, "zz-ZZ", play.EditImagePhoneScreenshots, []byte("\x89PNG\r\n\x1a\n"+name))
+	}
+
+	api := server.Api()
+
+	edit, err := api.Edits.Insert(ctx, token, packageName)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	cancelledCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	ch := make(chan io.ReadSeeker, 1)
+	ch <- bytes.NewReader([]byte("\x89PNG\r\n\x1a\nnew"))
+	close(ch)
+
+	// Cancellation between image requests stops the task before the next one
+	err = NewUpsert(api, token, packageName, edit.Id, WithObserver(ObserverFunc(func(event Event) {
+		if event.Type == EventImageDeleted {
+			cancel()
+		}
+	}))).Run(cancelledCtx, &play.Listing{Language: "zz-ZZ"}, nil, ImageTypeSources{play.EditImagePhoneScreenshots: ch})
+	assert.True(t, errors.Is(err, context.Canceled))
+
+	assert.Len(t, server.EditImages(edit.Id, "zz-ZZ", play.EditImagePhoneScreenshots), 1)
+}
+
+func TestUpsert_RunCancelledWhileProducingImages(t *testing.T) {
+	server := playtest.NewServer()
+	defer server.Close()
+
+	api := server.Api()
+
+	edit, err := api.Edits.Insert(ctx, token, packageName)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	cancelledCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Producer is cancelled after the first image is consumed and never
+	// closes the channel
+	ch := make(chan io.ReadSeeker)
+	go func() {
+		ch <- bytes.NewReader([]byte("\x89PNG\r\n\x1a\nfirst"))
+		cancel()
+	}()
+
+	err = NewUpsert(api, token, packageName, edit.Id).
+		Run(cancelledCtx, &play.Listing{Language: "zz-ZZ"}, nil, ImageTypeSources{play.EditImagePhoneScreenshots: ch})
+	assert.True(t, errors.Is(err, context.Canceled))
+
+	assert.Len(t, server.EditListings(edit.Id), 1)
+	assert.Empty(t, server.EditImages(edit.Id, "zz-ZZ", play.EditImagePhoneScreenshots))
+}
+
+func TestUpsert_RunPartial(t *testing.T) {
+	server := playtest.NewServer()
+	defer server.Close()
+
+	server.SetListing(packageName, play.Listing{Language: "zz-ZZ", Title: "Old", Video: "https://youtu.be/video"})
+
+	api := server.Api()
+
+	edit, err := api.Edits.Insert(ctx, token, packageName)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// Only title is given, video stays as is
+	err = NewUpsert(api, token, packageName, edit.Id).
+		Run(ctx, &play.Listing{Language: "zz-ZZ", Title: "New"}, []play.ListingField{play.ListingTitle}, ImageTypeSources{})
+	assert.NoError(t, err)
+
+	assert.Equal(t, []play.Listing{
+		{Language: "zz-ZZ", Title: "New", Video: "https://youtu.be/video"},
+	}, server.EditListings(edit.Id))
+}
+
+func TestUpsert_RunPartialCreatesNewLanguage(t *testing.T) {
+	server := playtest.NewServer()
+	defer server.Close()
+
+	api := server.Api()
+
+	edit, err := api.Edits.Insert(ctx, token, packageName)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	err = NewUpsert(api, token, packageName, edit.Id).
+		Run(ctx, &play.Listing{Language: "zz-ZZ", Title: "New"}, []play.ListingField{play.ListingTitle}, ImageTypeSources{})
+	assert.NoError(t, err)
+
+	assert.Equal(t, []play.Listing{
+		{Language: "zz-ZZ", Title: "New"},
+	}, server.EditListings(edit.Id))
+}
+
+func TestUpsert_RunDry(t *testing.T) {
+	server := playtest.NewServer()
+	defer server.Close()
+
+	data := []byte("\x89PNG\r\n\x1a\nold")
+	server.AddImage(packageName, "zz-ZZ", play.EditImagePhoneScreenshots, data)
+
+	edit, err := server.Api().Edits.Insert(ctx, token, packageName)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	api, dryRun := play.NewDryRunApi(server.Api())
+
+	ch := make(chan io.ReadSeeker, 2)
+	ch <- bytes.NewReader(data)
+	ch <- bytes.NewReader([]byte("\x89PNG\r\n\x1a\nnew"))
+	close(ch)
+
+	var events []Event
+
+	err = NewUpsert(api, token, packageName, edit.Id, WithObserver(ObserverFunc(func(event Event) {
+		events = append(events, event)
+	}))).Run(ctx, &play.Listing{Language: "zz-ZZ"}, nil, ImageTypeSources{play.EditImagePhoneScreenshots: ch})
+	assert.NoError(t, err)
+
+	// Events are marked, so that recorded uploads aren't taken for real ones
+	if assert.NotEmpty(t, events) {
+		for _, event := range events {
+			assert.True(t, event.DryRun, event.Type)
+		}
+	}
+
+	var operations []string
+	for _, call := range dryRun.Calls() {
+		operations = append(operations, call.Operation)
+	}
+	assert.Equal(t, []string{"listings.update", "images.upload"}, operations)
+
+	// Nothing is changed
+	assert.Empty(t, server.EditListings(edit.Id))
+	assert.Len(t, server.EditImages(edit.Id, "zz-ZZ", play.EditImagePhoneScreenshots), 1)
+}
+
+func TestUpsert_RunKeepsImagesOfEmptySource(t *testing.T) {
+	server := playtest.NewServer()
+	defer server.Close()
+
+	server.AddImage(packageName, "zz-ZZ", play.EditImagePhoneScreenshots, []byte("\x89PNG\r\n\x1a\nold"))
+
+	api := server.Api()
+
+	edit, err := api.Edits.Insert(ctx, token, packageName)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// E.g. insert without screenshots directory
+	ch := make(chan io.ReadSeeker)
+	close(ch)
+
+	err = NewUpsert(api, token, packageName, edit.Id).
+		Run(ctx, &play.Listing{Language: "zz-ZZ"}, nil, ImageTypeSources{play.EditImagePhoneScreenshots: ch})
+	assert.NoError(t, err)
+
+	assert.Len(t, server.EditImages(edit.Id, "zz-ZZ", play.EditImagePhoneScreenshots), 1)
 }
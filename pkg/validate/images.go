@@ -0,0 +1,165 @@
+package validate
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/jpeg"
+	_ "image/png"
+	"os"
+	"path/filepath"
+
+	"github.com/yurykabanov/google-play-edit/pkg/play"
+)
+
+// ImageRule describes Play requirements to images of a type, zero values mean
+// no restriction.
+type ImageRule struct {
+	// Exact size in pixels
+	Width  int
+	Height int
+
+	// Limits of the shorter and the longer side
+	MinSide int
+	MaxSide int
+
+	// Max ratio of the longer side to the shorter one
+	MaxAspectRatio float64
+
+	// Exact aspect ratio as width:height
+	RatioWidth  int
+	RatioHeight int
+
+	MaxFileSize int64
+	MaxCount    int
+
+	// Image has to be PNG with alpha channel
+	Alpha bool
+}
+
+const megabyte = 1024 * 1024
+
+var screenshotRule = ImageRule{
+	MinSide:        320,
+	MaxSide:        3840,
+	MaxAspectRatio: 2,
+	MaxFileSize:    8 * megabyte,
+	MaxCount:       8,
+}
+
+// ImageRules are requirements of Play Console to store listing assets.
+var ImageRules = map[play.EditImageType]ImageRule{
+	play.EditImageIcon:           {Width: 512, Height: 512, MaxFileSize: 1 * megabyte, MaxCount: 1, Alpha: true},
+	play.EditImageFeatureGraphic: {Width: 1024, Height: 500, MaxFileSize: 15 * megabyte, MaxCount: 1},
+	play.EditImagePromoGraphic:   {Width: 180, Height: 120, MaxFileSize: 15 * megabyte, MaxCount: 1},
+	play.EditImageTvBanner:       {Width: 1280, Height: 720, MaxFileSize: 15 * megabyte, MaxCount: 1},
+
+	play.EditImagePhoneScreenshots:     screenshotRule,
+	play.EditImageSevenInchScreenshots: screenshotRule,
+	play.EditImageTenInchScreenshots:   screenshotRule,
+	play.EditImageTvScreenshots: {
+		MinSide: 720, MaxSide: 3840, RatioWidth: 16, RatioHeight: 9, MaxFileSize: 8 * megabyte, MaxCount: 8,
+	},
+	play.EditImageWearScreenshots: {
+		MinSide: 384, MaxSide: 3840, RatioWidth: 1, RatioHeight: 1, MaxFileSize: 8 * megabyte, MaxCount: 8,
+	},
+}
+
+// Images checks image files given by type against ImageRules. Only image
+// headers are decoded, every violation is reported.
+func Images(images map[play.EditImageType][]string) []Violation {
+	var violations []Violation
+
+	for _, imageType := range play.EditImageTypes {
+		paths, ok := images[imageType]
+		if !ok {
+			continue
+		}
+
+		rule := ImageRules[imageType]
+
+		if rule.MaxCount > 0 && len(paths) > rule.MaxCount {
+			violations = append(violations, Violation{
+				Path:    filepath.Dir(paths[0]),
+				Message: fmt.Sprintf("%d %s, at most %d allowed", len(paths), imageType, rule.MaxCount),
+			})
+		}
+
+		for _, path := range paths {
+			for _, message := range checkImage(path, rule) {
+				violations = append(violations, Violation{Path: path, Message: message})
+			}
+		}
+	}
+
+	return violations
+}
+
+func checkImage(path string, rule ImageRule) []string {
+	info, err := os.Stat(path)
+	if err != nil {
+		return []string{err.Error()}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return []string{err.Error()}
+	}
+	defer f.Close()
+
+	config, format, err := image.DecodeConfig(f)
+	if err != nil {
+		return []string{fmt.Sprintf("unable to decode image: %s", err.Error())}
+	}
+
+	var problems []string
+
+	if rule.MaxFileSize > 0 && info.Size() > rule.MaxFileSize {
+		problems = append(problems, fmt.Sprintf("file size is %d bytes, at most %d allowed", info.Size(), rule.MaxFileSize))
+	}
+
+	width, height := config.Width, config.Height
+	short, long := width, height
+	if short > long {
+		short, long = long, short
+	}
+
+	if rule.Width > 0 && (width != rule.Width || height != rule.Height) {
+		problems = append(problems, fmt.Sprintf("size is %dx%d, %dx%d required", width, height, rule.Width, rule.Height))
+	}
+	if rule.MinSide > 0 && short < rule.MinSide {
+		problems = append(problems, fmt.Sprintf("size is %dx%d, sides have to be at least %d", width, height, rule.MinSide))
+	}
+	if rule.MaxSide > 0 && long > rule.MaxSide {
+		problems = append(problems, fmt.Sprintf("size is %dx%d, sides have to be at most %d", width, height, rule.MaxSide))
+	}
+	if rule.MaxAspectRatio > 0 && short > 0 && float64(long)/float64(short) > rule.MaxAspectRatio {
+		problems = append(problems, fmt.Sprintf("size is %dx%d, longer side is more than %g times the shorter one", width, height, rule.MaxAspectRatio))
+	}
+	if rule.RatioWidth > 0 && width*rule.RatioHeight != height*rule.RatioWidth {
+		problems = append(problems, fmt.Sprintf("size is %dx%d, aspect ratio %d:%d required", width, height, rule.RatioWidth, rule.RatioHeight))
+	}
+
+	if rule.Alpha && (format != "png" || !hasAlpha(config.ColorModel)) {
+		problems = append(problems, "PNG with alpha channel required")
+	}
+
+	return problems
+}
+
+func hasAlpha(model color.Model) bool {
+	switch model {
+	case color.NRGBAModel, color.NRGBA64Model, color.AlphaModel, color.Alpha16Model:
+		return true
+	}
+
+	if palette, ok := model.(color.Palette); ok {
+		for _, c := range palette {
+			if _, _, _, a := c.RGBA(); a != 0xffff {
+				return true
+			}
+		}
+	}
+
+	return false
+}
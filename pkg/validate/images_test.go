@@ -0,0 +1,93 @@
+package validate
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yurykabanov/google-play-edit/pkg/play"
+)
+
+func writeImage(t *testing.T, dir string, name string, img image.Image) string {
+	path := filepath.Join(dir, name)
+
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	if filepath.Ext(name) == ".jpg" {
+		require.NoError(t, jpeg.Encode(f, img, nil))
+	} else {
+		require.NoError(t, png.Encode(f, img))
+	}
+
+	return path
+}
+
+func opaque(width, height int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+
+	return img
+}
+
+func transparent(width, height int) image.Image {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	img.Set(0, 0, color.NRGBA{R: 1, A: 128})
+
+	return img
+}
+
+func TestImages(t *testing.T) {
+	dir := t.TempDir()
+
+	images := map[play.EditImageType][]string{
+		play.EditImageIcon:           {writeImage(t, dir, "icon.png", transparent(512, 512))},
+		play.EditImageFeatureGraphic: {writeImage(t, dir, "feature.jpg", opaque(1024, 500))},
+		play.EditImagePhoneScreenshots: {
+			writeImage(t, dir, "01.png", opaque(1080, 1920)),
+			writeImage(t, dir, "02.jpg", opaque(320, 640)),
+		},
+		play.EditImageWearScreenshots: {writeImage(t, dir, "wear.png", opaque(384, 384))},
+	}
+
+	assert.Empty(t, Images(images))
+}
+
+func TestImages_Violations(t *testing.T) {
+	dir := t.TempDir()
+
+	icon := writeImage(t, dir, "icon.png", opaque(512, 512))
+	feature := writeImage(t, dir, "feature.png", opaque(1000, 500))
+	small := writeImage(t, dir, "small.png", opaque(200, 300))
+	long := writeImage(t, dir, "long.png", opaque(400, 1000))
+	broken := filepath.Join(dir, "broken.png")
+	require.NoError(t, os.WriteFile(broken, []byte("not an image"), 0644))
+
+	screenshots := []string{small, long, broken}
+	for len(screenshots) < 9 {
+		screenshots = append(screenshots, writeImage(t, dir, "ok.png", opaque(320, 640)))
+	}
+
+	violations := Images(map[play.EditImageType][]string{
+		play.EditImageIcon:             {icon},
+		play.EditImageFeatureGraphic:   {feature},
+		play.EditImagePhoneScreenshots: screenshots,
+	})
+
+	var paths []string
+	for _, violation := range violations {
+		paths = append(paths, violation.Path)
+	}
+
+	// Every violation is reported in a single pass
+	assert.Equal(t, []string{feature, icon, dir, small, long, broken}, paths)
+}
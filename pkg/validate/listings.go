@@ -0,0 +1,105 @@
+package validate
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/rivo/uniseg"
+	"golang.org/x/text/unicode/norm"
+
+	"github.com/yurykabanov/google-play-edit/pkg/play"
+)
+
+// LanguageKey is the key of listing's language in violations.
+const LanguageKey = "language"
+
+// MaxListingLengths are limits of listing texts in user-perceived characters.
+var MaxListingLengths = map[play.ListingField]int{
+	play.ListingTitle:            30,
+	play.ListingShortDescription: 80,
+	play.ListingFullDescription:  4000,
+}
+
+// Languages are locales supported by Play store listings. Play adds new ones
+// from time to time, so unknown locales are reported as warnings only.
+var Languages = map[string]bool{
+	"af": true, "am": true, "ar": true, "az-AZ": true, "be": true, "bg": true, "bn-BD": true, "ca": true,
+	"cs-CZ": true, "da-DK": true, "de-DE": true, "el-GR": true, "en-AU": true, "en-CA": true, "en-GB": true,
+	"en-IN": true, "en-SG": true, "en-US": true, "en-ZA": true, "es-419": true, "es-ES": true, "es-US": true,
+	"et": true, "eu-ES": true, "fa": true, "fa-AE": true, "fa-AF": true, "fa-IR": true, "fi-FI": true,
+	"fil": true, "fr-CA": true, "fr-FR": true, "gl-ES": true, "gu": true, "hi-IN": true, "hr": true,
+	"hu-HU": true, "hy-AM": true, "id": true, "is-IS": true, "it-IT": true, "iw-IL": true, "ja-JP": true,
+	"ka-GE": true, "kk": true, "km-KH": true, "kn-IN": true, "ko-KR": true, "ky-KG": true, "lo-LA": true,
+	"lt": true, "lv": true, "mk-MK": true, "ml-IN": true, "mn-MN": true, "mr-IN": true, "ms": true,
+	"ms-MY": true, "my-MM": true, "ne-NP": true, "nl-NL": true, "no-NO": true, "pa": true, "pl-PL": true,
+	"pt-BR": true, "pt-PT": true, "rm": true, "ro": true, "ru-RU": true, "si-LK": true, "sk": true,
+	"sl": true, "sq": true, "sr": true, "sv-SE": true, "sw": true, "ta-IN": true, "te-IN": true, "th": true,
+	"tr-TR": true, "uk": true, "ur": true, "vi": true, "zh-CN": true, "zh-HK": true, "zh-TW": true, "zu": true,
+}
+
+var youtubeId = regexp.MustCompile(`^[A-Za-z0-9_-]{11}$`)
+
+// Listing normalizes given fields of listing (all of them when fields is nil)
+// to NFC in place and checks them against Play requirements. Violations have
+// empty Path and are keyed by field names (or LanguageKey).
+func Listing(listing *play.Listing, fields []play.ListingField) []Violation {
+	var violations []Violation
+
+	if !Languages[listing.Language] {
+		violations = append(violations, Violation{
+			Key:     LanguageKey,
+			Message: fmt.Sprintf("unknown language %q", listing.Language),
+			Warning: true,
+		})
+	}
+
+	if fields == nil {
+		fields = play.ListingFields
+	}
+
+	for _, field := range fields {
+		value := norm.NFC.String(listing.Field(field))
+		listing.SetField(field, value)
+
+		if max, ok := MaxListingLengths[field]; ok {
+			if length := uniseg.GraphemeClusterCount(value); length > max {
+				violations = append(violations, Violation{
+					Key:     string(field),
+					Message: fmt.Sprintf("%d characters, at most %d allowed", length, max),
+				})
+			}
+		}
+
+		if field == play.ListingVideo && value != "" && !isYoutubeUrl(value) {
+			violations = append(violations, Violation{
+				Key:     string(field),
+				Message: fmt.Sprintf("%q is not a YouTube video URL", value),
+			})
+		}
+	}
+
+	return violations
+}
+
+// isYoutubeUrl accepts youtube.com/watch?v=, youtube.com/embed/ and youtu.be
+// links to a video.
+func isYoutubeUrl(value string) bool {
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return false
+	}
+
+	switch strings.TrimPrefix(strings.TrimPrefix(strings.ToLower(u.Host), "www."), "m.") {
+	case "youtube.com":
+		if u.Path == "/watch" {
+			return youtubeId.MatchString(u.Query().Get("v"))
+		}
+		return strings.HasPrefix(u.Path, "/embed/") && youtubeId.MatchString(strings.TrimPrefix(u.Path, "/embed/"))
+	case "youtu.be":
+		return youtubeId.MatchString(strings.TrimPrefix(u.Path, "/"))
+	}
+
+	return false
+}
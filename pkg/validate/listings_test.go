@@ -0,0 +1,50 @@
+package validate
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/yurykabanov/google-play-edit/pkg/play"
+)
+
+func TestListing(t *testing.T) {
+	// "e" followed by combining acute accent is a single character, as well
+	// as a family emoji joined by ZWJ
+	title := strings.Repeat("e\u0301", 20) + strings.Repeat("👨\u200d👩\u200d👧", 10)
+
+	listing := &play.Listing{Language: "fr-FR", Title: title, Video: "https://youtu.be/dQw4w9WgXcQ"}
+
+	assert.Empty(t, Listing(listing, nil))
+	assert.Equal(t, strings.Repeat("\u00e9", 20)+strings.Repeat("👨\u200d👩\u200d👧", 10), listing.Title, "title should be NFC")
+
+	listing = &play.Listing{Language: "fr", Title: title + "!", Video: "https://vimeo.com/123"}
+
+	assert.Equal(t, []Violation{
+		{Key: LanguageKey, Message: `unknown language "fr"`, Warning: true},
+		{Key: "title", Message: "31 characters, at most 30 allowed"},
+		{Key: "video", Message: `"https://vimeo.com/123" is not a YouTube video URL`},
+	}, Listing(listing, nil))
+
+	// Only given fields are checked
+	listing = &play.Listing{Language: "en-US", Title: title + "!", ShortDescription: "Short"}
+	assert.Empty(t, Listing(listing, []play.ListingField{play.ListingShortDescription}))
+}
+
+func TestIsYoutubeUrl(t *testing.T) {
+	for value, valid := range map[string]bool{
+		"https://www.youtube.com/watch?v=dQw4w9WgXcQ": true,
+		"http://youtube.com/watch?v=dQw4w9WgXcQ&t=1":  true,
+		"https://m.youtube.com/watch?v=dQw4w9WgXcQ":   true,
+		"https://www.youtube.com/embed/dQw4w9WgXcQ":   true,
+		"https://youtu.be/dQw4w9WgXcQ":                true,
+		"https://youtube.com/watch?v=short":           false,
+		"https://youtube.com/channel/dQw4w9WgXcQ":     false,
+		"ftp://youtu.be/dQw4w9WgXcQ":                  false,
+		"youtu.be/dQw4w9WgXcQ":                        false,
+		"https://notyoutube.com/watch?v=dQw4w9WgXcQ":  false,
+	} {
+		assert.Equal(t, valid, isYoutubeUrl(value), value)
+	}
+}
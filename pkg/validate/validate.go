@@ -0,0 +1,41 @@
+// Package validate checks listings and images locally against Play
+// requirements, so that problems are found before anything is uploaded.
+package validate
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Violation is a single broken requirement, Path points to the offending file
+// (or a row in it) and Key to the offending key, if any.
+type Violation struct {
+	Path    string
+	Key     string
+	Message string
+
+	// Warning doesn't block anything, requirement may be outdated
+	Warning bool
+}
+
+func (violation Violation) String() string {
+	if violation.Key != "" {
+		return fmt.Sprintf("%s: %s: %s", violation.Path, violation.Key, violation.Message)
+	}
+
+	return fmt.Sprintf("%s: %s", violation.Path, violation.Message)
+}
+
+// Error holds all violations found in a single pass.
+type Error struct {
+	Violations []Violation
+}
+
+func (err Error) Error() string {
+	lines := make([]string, 0, len(err.Violations))
+	for _, violation := range err.Violations {
+		lines = append(lines, violation.String())
+	}
+
+	return fmt.Sprintf("%d validation error(s):\n- %s", len(err.Violations), strings.Join(lines, "\n- "))
+}